@@ -13,6 +13,7 @@ import (
 	"mastery-project/internal/router"
 	"mastery-project/internal/server"
 	"mastery-project/internal/service"
+	"mastery-project/internal/storage"
 	"net/http"
 	"os"
 	"os/signal"
@@ -38,20 +39,50 @@ func main() {
 
 	repos := repository.NewRepository(srv.Db.Pool)
 
-	services, serviceErr := service.NewServices(repos)
+	//uploaded files live in the configured blob store
+	blobs, err := storage.New(context.Background(), cfg.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	services, serviceErr := service.NewServices(cfg, repos, blobs)
 	if serviceErr != nil {
 		panic(serviceErr)
 	}
+	if err := services.Import.RecoverJobs(context.Background()); err != nil {
+		slog.Error(err.Error())
+	}
+
 	//setup handlers
-	handlers := handler.NewHandlers(cfg, services)
+	handlers, err := handler.NewHandlers(cfg, services, blobs)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	authMW := middleware.NewAuthMiddleware(repos.Session)
-	r := router.NewRouter(handlers, authMW)
+	idempotencyMW := middleware.NewIdempotencyMiddleware(repos.Idempotency, cfg.Idempotency, handlers.MaxBody())
+	r := router.NewRouter(handlers, authMW, idempotencyMW)
 
 	srv.SetupHttpServer(r)
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 
+	//drop expired idempotency keys in the background
+	go idempotencyMW.PurgeExpired(ctx, time.Hour)
+
+	//drop abandoned resumable uploads in the background
+	go services.Upload.PurgeExpired(ctx, time.Hour)
+
+	//render image variants in the background
+	go services.Variant.Run(ctx)
+
+	//record the size and checksum of attachments migrated without them
+	go func() {
+		if err := services.Item.BackfillAttachments(ctx, blobs); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
 	//start server
 	go func() {
 		if err := srv.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -8,9 +8,14 @@ import (
 )
 
 type Config struct {
-	Database Database
-	Server   Server
-	ENV      string
+	Database    Database
+	Server      Server
+	Items       Items
+	Idempotency Idempotency
+	Links       Links
+	Storage     Storage
+	Images      Images
+	ENV         string
 }
 
 type Database struct {
@@ -29,6 +34,91 @@ type Server struct {
 	IdleTimeout  int
 }
 
+type Items struct {
+	// RequireIfMatch rejects PATCH/DELETE on items without an If-Match header.
+	RequireIfMatch bool
+	// MaxAttachments caps the number of attachments a single item can hold.
+	MaxAttachments int
+	// MaxBulkOperations caps the number of operations in one POST /items/bulk.
+	MaxBulkOperations int
+	// ImportMaxBytes caps the size of an import file.
+	ImportMaxBytes int
+	// ImportAsyncRows is the row count above which an import runs as a background job.
+	ImportAsyncRows int
+	// UploadTypes lists the file types that may be uploaded as name:max_size
+	// entries, e.g. "jpeg:5MB,pdf:10MB".
+	UploadTypes string
+	// QuotaMaxBytes and QuotaMaxItems cap the file bytes and the number of
+	// items each user's items may take up, unless an admin overrides them for
+	// the user; 0 is unlimited.
+	QuotaMaxBytes int
+	QuotaMaxItems int
+	// UploadExpiryHours is how long a resumable upload is kept after its last
+	// chunk arrived, whether or not an item used it.
+	UploadExpiryHours int
+	// ImageURLTTLMinutes is how long a signed URL from GET /items/{id}/image/url stays valid.
+	ImageURLTTLMinutes int
+	// FileCacheMaxAge is the Cache-Control max-age, in seconds, of images and
+	// attachment files served to signed-in users; 0 makes clients revalidate
+	// every time.
+	FileCacheMaxAge int
+	// PublicFileCacheMaxAge is the same for files of unlisted and public items,
+	// which shared caches may keep that long after an item turns private.
+	PublicFileCacheMaxAge int
+}
+
+type Idempotency struct {
+	// TTLHours is how long a key is remembered after its first use.
+	TTLHours int
+	// MaxBodyBytes caps the request body that is buffered to fingerprint a
+	// request. It is raised to the largest body the handlers accept, so 0
+	// follows the upload and import limits.
+	MaxBodyBytes int
+}
+
+type Links struct {
+	// Secret signs the short-lived image URLs on public link pages and those
+	// handed out for <img> tags. When empty a random secret is used, so those
+	// URLs stop working after a restart.
+	Secret string
+	// ImageURLTTLMinutes is how long a signed image URL stays valid.
+	ImageURLTTLMinutes int
+}
+
+type Images struct {
+	// Variants lists the resized renditions generated for every uploaded item
+	// image as name:max_size:format entries; empty disables them.
+	Variants string
+	// Workers is the number of images resized concurrently.
+	Workers int
+	// QueueSize caps the images waiting for a worker; uploads beyond it get
+	// their variants on the next start.
+	QueueSize int
+	// MaxDimension and MaxPixels bound the width, height and area of images
+	// that are decoded, refusing decompression bombs.
+	MaxDimension int
+	MaxPixels    int
+	// ExtractMetadata copies the dimensions and capture time of a new item's
+	// image into its metadata.
+	ExtractMetadata bool
+}
+
+type Storage struct {
+	// Driver selects where uploads are stored: "local" or "s3".
+	Driver string
+	// LocalRoot is the directory the local driver stores files in.
+	LocalRoot string
+	// S3Endpoint is the host[:port] of an S3-compatible service, e.g. s3.amazonaws.com or localhost:9000.
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+	// S3Prefix is prepended to every object key, so several apps can share a bucket.
+	S3Prefix string
+}
+
 func LoadConfig() (*Config, error) {
 	_ = godotenv.Load()
 	return &Config{
@@ -47,6 +137,47 @@ func LoadConfig() (*Config, error) {
 			WriteTimeout: GetEnvInt("WRITE_TIMEOUT", 0),
 			IdleTimeout:  GetEnvInt("IDLE_TIMEOUT", 0),
 		},
+		Items: Items{
+			RequireIfMatch:        GetEnvBool("ITEMS_REQUIRE_IF_MATCH", false),
+			MaxAttachments:        GetEnvInt("ITEMS_MAX_ATTACHMENTS", 10),
+			MaxBulkOperations:     GetEnvInt("ITEMS_BULK_MAX_OPERATIONS", 500),
+			ImportMaxBytes:        GetEnvInt("ITEMS_IMPORT_MAX_BYTES", 10<<20),
+			ImportAsyncRows:       GetEnvInt("ITEMS_IMPORT_ASYNC_ROWS", 1000),
+			UploadTypes:           GetEnv("UPLOAD_TYPES", "jpeg:5MB,png:5MB,gif:5MB,webp:5MB,pdf:10MB"),
+			UploadExpiryHours:     GetEnvInt("UPLOAD_EXPIRY_HOURS", 24),
+			QuotaMaxBytes:         GetEnvInt("QUOTA_MAX_BYTES", 0),
+			QuotaMaxItems:         GetEnvInt("QUOTA_MAX_ITEMS", 0),
+			ImageURLTTLMinutes:    GetEnvInt("ITEMS_IMAGE_URL_TTL_MINUTES", 60),
+			FileCacheMaxAge:       GetEnvInt("ITEMS_FILE_CACHE_MAX_AGE", 0),
+			PublicFileCacheMaxAge: GetEnvInt("ITEMS_PUBLIC_FILE_CACHE_MAX_AGE", 60),
+		},
+		Idempotency: Idempotency{
+			TTLHours:     GetEnvInt("IDEMPOTENCY_TTL_HOURS", 24),
+			MaxBodyBytes: GetEnvInt("IDEMPOTENCY_MAX_BODY_BYTES", 0),
+		},
+		Links: Links{
+			Secret:             GetEnv("SHARE_LINK_SECRET", ""),
+			ImageURLTTLMinutes: GetEnvInt("SHARE_LINK_IMAGE_TTL_MINUTES", 15),
+		},
+		Storage: Storage{
+			Driver:      GetEnv("STORAGE_DRIVER", "local"),
+			LocalRoot:   GetEnv("STORAGE_LOCAL_ROOT", "uploads"),
+			S3Endpoint:  GetEnv("STORAGE_S3_ENDPOINT", ""),
+			S3Region:    GetEnv("STORAGE_S3_REGION", ""),
+			S3Bucket:    GetEnv("STORAGE_S3_BUCKET", ""),
+			S3AccessKey: GetEnv("STORAGE_S3_ACCESS_KEY", ""),
+			S3SecretKey: GetEnv("STORAGE_S3_SECRET_KEY", ""),
+			S3UseSSL:    GetEnvBool("STORAGE_S3_USE_SSL", true),
+			S3Prefix:    GetEnv("STORAGE_S3_PREFIX", ""),
+		},
+		Images: Images{
+			Variants:        GetEnv("IMAGE_VARIANTS", "thumb:200:jpeg,medium:800:jpeg,webp:1200:webp"),
+			Workers:         GetEnvInt("IMAGE_WORKERS", 2),
+			QueueSize:       GetEnvInt("IMAGE_QUEUE_SIZE", 100),
+			MaxDimension:    GetEnvInt("IMAGE_MAX_DIMENSION", 10000),
+			MaxPixels:       GetEnvInt("IMAGE_MAX_PIXELS", 50_000_000),
+			ExtractMetadata: GetEnvBool("IMAGE_EXTRACT_METADATA", false),
+		},
 	}, nil
 }
 
@@ -69,3 +200,15 @@ func GetEnvInt(key string, fallback int) int {
 	}
 	return valueInt
 }
+
+func GetEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	valueBool, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+	return valueBool
+}
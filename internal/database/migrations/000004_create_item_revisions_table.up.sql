@@ -0,0 +1,27 @@
+CREATE TABLE item_revisions (
+                                id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+                                revision INTEGER NOT NULL,
+                                author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+                                title VARCHAR(255) NOT NULL,
+                                description TEXT NOT NULL,
+                                changed_fields TEXT[] NOT NULL DEFAULT '{}',
+                                created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                                UNIQUE (item_id, revision)
+);
+
+-- revisions are an audit trail: rows may only disappear together with their item
+CREATE OR REPLACE FUNCTION item_revisions_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'item revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER item_revisions_no_update
+    BEFORE UPDATE ON item_revisions
+    FOR EACH ROW EXECUTE FUNCTION item_revisions_immutable();
+
+-- existing items start their history at revision 1
+INSERT INTO item_revisions (item_id, revision, author_id, title, description, changed_fields, created_at)
+SELECT id, 1, user_id, title, description, ARRAY['title', 'description'], created_at
+FROM items;
@@ -0,0 +1,30 @@
+CREATE TABLE item_attachments (
+                                  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                  item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+                                  position INTEGER NOT NULL,
+                                  original_name VARCHAR(255) NOT NULL,
+                                  file_name TEXT NOT NULL,
+                                  size BIGINT NOT NULL DEFAULT 0,
+                                  mime_type VARCHAR(255) NOT NULL DEFAULT '',
+                                  checksum VARCHAR(64) NOT NULL DEFAULT '',
+                                  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    -- deferred so a reorder can shuffle positions inside one transaction
+                                  CONSTRAINT item_attachments_position_key UNIQUE (item_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX idx_item_attachments_file_name ON item_attachments (file_name);
+CREATE INDEX IF NOT EXISTS idx_items_file_path ON items (file_path);
+
+-- the existing image moves to the first attachment. Its size and checksum were
+-- never recorded; the server reads the file and fills them in on startup
+INSERT INTO item_attachments (item_id, position, original_name, file_name, mime_type, created_at)
+SELECT id,
+       0,
+       file_path,
+       file_path,
+       CASE WHEN LOWER(file_path) LIKE '%.png' THEN 'image/png' ELSE 'image/jpeg' END,
+       created_at
+FROM items
+WHERE file_path IS NOT NULL AND file_path <> '';
+
+UPDATE items SET file_path = NULL WHERE file_path IS NOT NULL AND file_path <> '';
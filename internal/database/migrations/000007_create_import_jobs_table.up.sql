@@ -0,0 +1,18 @@
+CREATE TABLE import_jobs (
+                             id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                             user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             format VARCHAR(16) NOT NULL,
+                             status VARCHAR(16) NOT NULL DEFAULT 'pending'
+                                 CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+                             total_rows INTEGER NOT NULL DEFAULT 0,
+                             processed_rows INTEGER NOT NULL DEFAULT 0,
+                             imported_rows INTEGER NOT NULL DEFAULT 0,
+                             failed_rows INTEGER NOT NULL DEFAULT 0,
+                             errors JSONB NOT NULL DEFAULT '[]',
+                             error TEXT NOT NULL DEFAULT '',
+                             created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                             updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                             finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_import_jobs_user_id ON import_jobs (user_id);
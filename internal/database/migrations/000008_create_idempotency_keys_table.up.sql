@@ -0,0 +1,16 @@
+CREATE TABLE idempotency_keys (
+                                  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                  idempotency_key VARCHAR(255) NOT NULL,
+                                  method VARCHAR(16) NOT NULL,
+                                  path TEXT NOT NULL,
+                                  fingerprint CHAR(64) NOT NULL,
+    -- NULL while the original request is still being processed
+                                  status_code INTEGER,
+                                  response_headers JSONB NOT NULL DEFAULT '{}',
+                                  response_body BYTEA,
+                                  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                                  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                                  PRIMARY KEY (user_id, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,10 @@
+CREATE TABLE item_shares (
+                             item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+                             user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             permission VARCHAR(16) NOT NULL CHECK (permission IN ('viewer', 'editor')),
+                             created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                             updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                             PRIMARY KEY (item_id, user_id)
+);
+
+CREATE INDEX idx_item_shares_user_id ON item_shares (user_id);
@@ -0,0 +1,15 @@
+CREATE TABLE item_links (
+                            id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                            item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+                            created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                            token_hash VARCHAR(64) NOT NULL UNIQUE,
+                            password_hash TEXT,
+                            expires_at TIMESTAMP WITH TIME ZONE,
+                            max_views INTEGER CHECK (max_views > 0),
+                            view_count INTEGER NOT NULL DEFAULT 0,
+                            revoked_at TIMESTAMP WITH TIME ZONE,
+                            last_viewed_at TIMESTAMP WITH TIME ZONE,
+                            created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_item_links_item_id ON item_links (item_id);
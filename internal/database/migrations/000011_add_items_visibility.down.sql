@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_items_public_created_at;
+ALTER TABLE items DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,4 @@
+ALTER TABLE items ADD COLUMN IF NOT EXISTS visibility VARCHAR(16) NOT NULL DEFAULT 'private'
+    CHECK (visibility IN ('private', 'unlisted', 'public'));
+
+CREATE INDEX IF NOT EXISTS idx_items_public_created_at ON items (created_at DESC) WHERE visibility = 'public';
@@ -0,0 +1,24 @@
+CREATE TABLE collections (
+                             id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                             user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             parent_id UUID REFERENCES collections(id) ON DELETE CASCADE,
+                             name VARCHAR(255) NOT NULL,
+                             description TEXT NOT NULL DEFAULT '',
+                             cover_item_id UUID REFERENCES items(id) ON DELETE SET NULL,
+                             created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                             updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                             CHECK (parent_id <> id)
+);
+
+CREATE INDEX idx_collections_user_id ON collections (user_id);
+CREATE INDEX idx_collections_parent_id ON collections (parent_id);
+
+-- deleting a collection or an item only removes the membership, never the item
+CREATE TABLE collection_items (
+                                  collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+                                  item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+                                  added_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                                  PRIMARY KEY (collection_id, item_id)
+);
+
+CREATE INDEX idx_collection_items_item_id ON collection_items (item_id);
@@ -0,0 +1,21 @@
+-- replies hang off top-level comments only; deleting a comment deletes its replies
+CREATE TABLE item_comments (
+                               id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                               item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+                               user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+                               parent_id UUID REFERENCES item_comments(id) ON DELETE CASCADE,
+                               body TEXT NOT NULL,
+                               created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                               updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_item_comments_item_id ON item_comments (item_id, created_at) WHERE parent_id IS NULL;
+CREATE INDEX idx_item_comments_parent_id ON item_comments (parent_id, created_at);
+
+CREATE TABLE comment_mentions (
+                                  comment_id UUID NOT NULL REFERENCES item_comments(id) ON DELETE CASCADE,
+                                  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                  PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE INDEX idx_comment_mentions_user_id ON comment_mentions (user_id);
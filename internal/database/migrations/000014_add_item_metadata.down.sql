@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_metadata_schemas;
+DROP INDEX IF EXISTS idx_items_metadata;
+ALTER TABLE items DROP COLUMN IF EXISTS metadata;
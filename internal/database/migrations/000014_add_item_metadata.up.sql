@@ -0,0 +1,11 @@
+ALTER TABLE items ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'
+    CHECK (jsonb_typeof(metadata) = 'object');
+
+-- jsonb_ops supports both @> (equality filters) and ? (key presence for range filters)
+CREATE INDEX IF NOT EXISTS idx_items_metadata ON items USING GIN (metadata);
+
+CREATE TABLE IF NOT EXISTS user_metadata_schemas (
+                                user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                schema JSONB NOT NULL,
+                                updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
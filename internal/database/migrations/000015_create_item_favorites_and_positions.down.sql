@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS item_positions;
+DROP TABLE IF EXISTS item_favorites;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS item_favorites (
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+                                created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                                PRIMARY KEY (user_id, item_id)
+);
+
+-- position is a fractional ranking key; keys compare byte-wise, hence the "C" collation
+CREATE TABLE IF NOT EXISTS item_positions (
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+                                position TEXT COLLATE "C" NOT NULL,
+                                PRIMARY KEY (user_id, item_id),
+                                UNIQUE (user_id, position)
+);
+
+-- existing items keep their newest-first order; the keys are fixed-width and
+-- never end in '0' so new keys can always be generated around them
+INSERT INTO item_positions (user_id, item_id, position)
+SELECT user_id, item_id, to_char(ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC, item_id), 'FM0000000000') || 'V'
+FROM (
+    SELECT user_id, id AS item_id, created_at FROM items
+    UNION
+    SELECT s.user_id, s.item_id, i.created_at FROM item_shares s JOIN items i ON i.id = s.item_id
+) visible;
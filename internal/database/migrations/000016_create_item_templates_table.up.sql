@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS item_templates (
+                                id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                name VARCHAR(100) NOT NULL,
+                                title VARCHAR(255) NOT NULL,
+                                description TEXT NOT NULL,
+                                visibility VARCHAR(16) NOT NULL DEFAULT 'private'
+                                    CHECK (visibility IN ('private', 'unlisted', 'public')),
+                                metadata JSONB NOT NULL DEFAULT '{}',
+                                created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                                updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_templates_user_id ON item_templates (user_id, name);
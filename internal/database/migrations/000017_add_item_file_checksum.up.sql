@@ -0,0 +1,3 @@
+-- SHA-256 of the item's image, used as its strong ETag; images uploaded before
+-- this column existed have no checksum and are hashed when served
+ALTER TABLE items ADD COLUMN IF NOT EXISTS file_checksum VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,14 @@
+-- resized renditions of uploaded images, keyed by the original's file name so
+-- items sharing a file (duplicates) share its variants too
+CREATE TABLE IF NOT EXISTS image_variants (
+                                file_path TEXT NOT NULL,
+                                name VARCHAR(32) NOT NULL,
+                                file_name TEXT NOT NULL,
+                                width INTEGER NOT NULL,
+                                height INTEGER NOT NULL,
+                                size BIGINT NOT NULL,
+                                mime_type VARCHAR(100) NOT NULL,
+                                checksum VARCHAR(64) NOT NULL,
+                                created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                                PRIMARY KEY (file_path, name)
+);
@@ -0,0 +1,16 @@
+-- resumable (tus) uploads; chunks lists the blobs holding the data received so
+-- far, in order
+CREATE TABLE IF NOT EXISTS uploads (
+                                id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                file_name TEXT NOT NULL,
+                                length BIGINT NOT NULL,
+                                upload_offset BIGINT NOT NULL DEFAULT 0,
+                                metadata TEXT NOT NULL DEFAULT '',
+                                chunks TEXT[] NOT NULL DEFAULT '{}',
+                                expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                                created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                                updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_uploads_expires_at ON uploads (expires_at);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS item_attachments_blob_refs ON item_attachments;
+DROP TRIGGER IF EXISTS items_blob_refs ON items;
+DROP FUNCTION IF EXISTS item_attachments_blob_refs();
+DROP FUNCTION IF EXISTS items_blob_refs();
+DROP FUNCTION IF EXISTS blob_ref(TEXT, INTEGER);
+DROP TABLE IF EXISTS blobs;
+ALTER TABLE items DROP COLUMN IF EXISTS file_size;
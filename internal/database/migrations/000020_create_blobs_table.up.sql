@@ -0,0 +1,67 @@
+ALTER TABLE items ADD COLUMN IF NOT EXISTS file_size BIGINT NOT NULL DEFAULT 0;
+
+-- stored files; new ones are keyed by the SHA-256 of their content, so a file
+-- uploaded twice is stored once. refs counts the item images and attachments
+-- pointing at a file and is kept by the triggers below, so every write path and
+-- cascade stays in step. pins counts uploads that stored the file but have not
+-- saved the row referencing it yet. A file is deleted once both reach zero.
+CREATE TABLE IF NOT EXISTS blobs (
+                                key TEXT PRIMARY KEY,
+                                checksum VARCHAR(64) NOT NULL,
+                                size BIGINT NOT NULL DEFAULT 0,
+                                refs INTEGER NOT NULL DEFAULT 0 CHECK (refs >= 0),
+                                pins INTEGER NOT NULL DEFAULT 0 CHECK (pins >= 0),
+                                created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+                                updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- files stored before this migration keep their random names
+INSERT INTO blobs (key, checksum, size, refs)
+SELECT key, MAX(checksum), MAX(size), COUNT(*)
+FROM (
+    SELECT file_path AS key, file_checksum AS checksum, 0 AS size FROM items WHERE file_path IS NOT NULL
+    UNION ALL
+    SELECT file_name, checksum, size FROM item_attachments
+) refs
+GROUP BY key
+ON CONFLICT (key) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION blob_ref(blob_key TEXT, delta INTEGER) RETURNS VOID AS $$
+BEGIN
+    IF blob_key IS NOT NULL AND blob_key <> '' THEN
+        UPDATE blobs SET refs = refs + delta, updated_at = NOW() WHERE key = blob_key;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION items_blob_refs() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM blob_ref(NEW.file_path, 1);
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM blob_ref(OLD.file_path, -1);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION item_attachments_blob_refs() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM blob_ref(NEW.file_name, 1);
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM blob_ref(OLD.file_name, -1);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER items_blob_refs
+    AFTER INSERT OR DELETE OR UPDATE OF file_path ON items
+    FOR EACH ROW EXECUTE FUNCTION items_blob_refs();
+
+CREATE TRIGGER item_attachments_blob_refs
+    AFTER INSERT OR DELETE OR UPDATE OF file_name ON item_attachments
+    FOR EACH ROW EXECUTE FUNCTION item_attachments_blob_refs();
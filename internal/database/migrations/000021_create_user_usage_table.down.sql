@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS item_attachments_usage ON item_attachments;
+DROP TRIGGER IF EXISTS items_usage_delete ON items;
+DROP TRIGGER IF EXISTS items_usage ON items;
+DROP FUNCTION IF EXISTS item_attachments_usage();
+DROP FUNCTION IF EXISTS items_usage();
+DROP FUNCTION IF EXISTS add_usage(UUID, BIGINT, INTEGER);
+DROP TABLE IF EXISTS user_usage;
+ALTER TABLE users DROP COLUMN IF EXISTS quota_items;
+ALTER TABLE users DROP COLUMN IF EXISTS quota_bytes;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,82 @@
+-- admins can override the configured quotas per user; NULL keeps the default
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quota_bytes BIGINT CHECK (quota_bytes >= 0);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quota_items INTEGER CHECK (quota_items >= 0);
+
+-- what each user's items take up: image and attachment bytes, counted per item
+-- even where items share a file, and the number of items. The triggers below
+-- keep it in step with every write path and cascade.
+CREATE TABLE IF NOT EXISTS user_usage (
+                                user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                bytes BIGINT NOT NULL DEFAULT 0,
+                                items INTEGER NOT NULL DEFAULT 0,
+                                updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO user_usage (user_id, bytes, items)
+SELECT i.user_id,
+       SUM(i.file_size + COALESCE((SELECT SUM(a.size) FROM item_attachments a WHERE a.item_id = i.id), 0)),
+       COUNT(*)
+FROM items i
+GROUP BY i.user_id
+ON CONFLICT (user_id) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION add_usage(owner UUID, delta_bytes BIGINT, delta_items INTEGER) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO user_usage (user_id, bytes, items)
+    SELECT owner, delta_bytes, delta_items
+    WHERE EXISTS (SELECT 1 FROM users WHERE id = owner)
+    ON CONFLICT (user_id) DO UPDATE
+        SET bytes = user_usage.bytes + EXCLUDED.bytes,
+            items = user_usage.items + EXCLUDED.items,
+            updated_at = NOW();
+END;
+$$ LANGUAGE plpgsql;
+
+-- a deleted item gives back its attachments' bytes too: they are deleted by the
+-- cascade after the item row is gone, when their owner can no longer be found
+CREATE OR REPLACE FUNCTION items_usage() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM add_usage(NEW.user_id, NEW.file_size, 1);
+    ELSIF TG_OP = 'UPDATE' THEN
+        PERFORM add_usage(NEW.user_id, NEW.file_size - OLD.file_size, 0);
+    ELSE
+        PERFORM add_usage(OLD.user_id,
+            -(OLD.file_size + COALESCE((SELECT SUM(size) FROM item_attachments WHERE item_id = OLD.id), 0)),
+            -1);
+        RETURN OLD;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION item_attachments_usage() RETURNS TRIGGER AS $$
+DECLARE
+    owner UUID;
+BEGIN
+    SELECT user_id INTO owner FROM items WHERE id = COALESCE(NEW.item_id, OLD.item_id);
+    IF owner IS NULL THEN
+        RETURN NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM add_usage(owner, NEW.size, 0);
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM add_usage(owner, -OLD.size, 0);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER items_usage
+    AFTER INSERT OR UPDATE OF file_size ON items
+    FOR EACH ROW EXECUTE FUNCTION items_usage();
+
+CREATE TRIGGER items_usage_delete
+    BEFORE DELETE ON items
+    FOR EACH ROW EXECUTE FUNCTION items_usage();
+
+CREATE TRIGGER item_attachments_usage
+    AFTER INSERT OR DELETE OR UPDATE OF size ON item_attachments
+    FOR EACH ROW EXECUTE FUNCTION item_attachments_usage();
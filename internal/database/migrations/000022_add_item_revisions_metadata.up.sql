@@ -0,0 +1,12 @@
+-- revisions snapshot the item's metadata so diffs show it and restores bring it
+-- back. Revisions older than this snapshot stay NULL: their metadata is unknown.
+ALTER TABLE item_revisions ADD COLUMN IF NOT EXISTS metadata JSONB;
+
+-- the latest revision of every item still matches the item
+ALTER TABLE item_revisions DISABLE TRIGGER item_revisions_no_update;
+UPDATE item_revisions r
+SET metadata = i.metadata
+FROM items i
+WHERE r.item_id = i.id
+  AND r.revision = (SELECT MAX(revision) FROM item_revisions WHERE item_id = i.id);
+ALTER TABLE item_revisions ENABLE TRIGGER item_revisions_no_update;
@@ -0,0 +1,13 @@
+-- revisions snapshot the item's visibility next to its metadata. Older
+-- revisions stay NULL: their visibility is unknown.
+ALTER TABLE item_revisions ADD COLUMN IF NOT EXISTS visibility VARCHAR(16)
+    CHECK (visibility IN ('private', 'unlisted', 'public'));
+
+-- the latest revision of every item still matches the item
+ALTER TABLE item_revisions DISABLE TRIGGER item_revisions_no_update;
+UPDATE item_revisions r
+SET visibility = i.visibility
+FROM items i
+WHERE r.item_id = i.id
+  AND r.revision = (SELECT MAX(revision) FROM item_revisions WHERE item_id = i.id);
+ALTER TABLE item_revisions ENABLE TRIGGER item_revisions_no_update;
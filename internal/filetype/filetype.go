@@ -0,0 +1,133 @@
+// Package filetype describes the kinds of files users may upload and the
+// deployment's allow-list of them.
+package filetype
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrNotAllowed = errors.New("file type not allowed")
+
+// Type is an uploadable kind of file.
+type Type struct {
+	Name     string
+	MimeType string
+	// Extensions are the lower-case file name extensions the type may use.
+	Extensions []string
+	// Image types can be item images and are re-encoded on upload.
+	Image bool
+	// Download types are served with Content-Disposition: attachment so
+	// browsers save them instead of rendering them in the page's origin.
+	Download bool
+}
+
+var known = []Type{
+	{Name: "jpeg", MimeType: "image/jpeg", Extensions: []string{".jpg", ".jpeg"}, Image: true},
+	{Name: "png", MimeType: "image/png", Extensions: []string{".png"}, Image: true},
+	{Name: "gif", MimeType: "image/gif", Extensions: []string{".gif"}, Image: true},
+	{Name: "webp", MimeType: "image/webp", Extensions: []string{".webp"}, Image: true},
+	{Name: "pdf", MimeType: "application/pdf", Extensions: []string{".pdf"}, Download: true},
+}
+
+// ByMIME returns the known type with the MIME type.
+func ByMIME(mimeType string) (Type, bool) {
+	for _, t := range known {
+		if t.MimeType == mimeType {
+			return t, true
+		}
+	}
+	return Type{}, false
+}
+
+// Rule allows one type up to MaxSize bytes.
+type Rule struct {
+	Type
+	MaxSize int64
+}
+
+// AllowList is the set of types a deployment accepts.
+type AllowList struct {
+	rules []Rule
+}
+
+// ParseAllowList reads a comma-separated list of name:max_size entries, such
+// as "jpeg:5MB,png:5MB,pdf:10MB". Sizes are bytes with an optional KB or MB
+// suffix.
+func ParseAllowList(spec string) (*AllowList, error) {
+	list := &AllowList{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, size, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("upload type %q: want name:max_size", entry)
+		}
+		i := slices.IndexFunc(known, func(t Type) bool { return t.Name == strings.ToLower(name) })
+		if i < 0 {
+			return nil, fmt.Errorf("upload type %q: unknown type", entry)
+		}
+		if _, ok := list.byName(known[i].Name); ok {
+			return nil, fmt.Errorf("upload type %q: listed twice", entry)
+		}
+		maxSize, err := parseSize(size)
+		if err != nil {
+			return nil, fmt.Errorf("upload type %q: %w", entry, err)
+		}
+		list.rules = append(list.rules, Rule{Type: known[i], MaxSize: maxSize})
+	}
+	if len(list.rules) == 0 {
+		return nil, errors.New("no upload types are allowed")
+	}
+	return list, nil
+}
+
+// ForExtension returns the rule for a file name extension, case-insensitively.
+func (l *AllowList) ForExtension(ext string) (Rule, error) {
+	ext = strings.ToLower(ext)
+	for _, rule := range l.rules {
+		if slices.Contains(rule.Extensions, ext) {
+			return rule, nil
+		}
+	}
+	return Rule{}, ErrNotAllowed
+}
+
+// MaxSize is the largest size any allowed type may have.
+func (l *AllowList) MaxSize() int64 {
+	var largest int64
+	for _, rule := range l.rules {
+		largest = max(largest, rule.MaxSize)
+	}
+	return largest
+}
+
+func (l *AllowList) byName(name string) (Rule, bool) {
+	for _, rule := range l.rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func parseSize(raw string) (int64, error) {
+	raw = strings.ToUpper(strings.TrimSpace(raw))
+	unit := int64(1)
+	switch {
+	case strings.HasSuffix(raw, "MB"):
+		unit, raw = 1<<20, strings.TrimSuffix(raw, "MB")
+	case strings.HasSuffix(raw, "KB"):
+		unit, raw = 1<<10, strings.TrimSuffix(raw, "KB")
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n <= 0 || n > (1<<40)/unit {
+		return 0, errors.New("max size must be a positive number of bytes, KB or MB")
+	}
+	return n * unit, nil
+}
@@ -0,0 +1,121 @@
+package filetype
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestParseAllowList(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    map[string]int64
+		maxSize int64
+	}{
+		{"jpeg:5MB", map[string]int64{"jpeg": 5 << 20}, 5 << 20},
+		{"jpeg:5MB,png:512KB,pdf:10MB", map[string]int64{"jpeg": 5 << 20, "png": 512 << 10, "pdf": 10 << 20}, 10 << 20},
+		{" GIF:100 , webp:2mb ,", map[string]int64{"gif": 100, "webp": 2 << 20}, 2 << 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			list, err := ParseAllowList(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseAllowList: %v", err)
+			}
+			if len(list.rules) != len(tt.want) {
+				t.Fatalf("got %d rules, want %d", len(list.rules), len(tt.want))
+			}
+			for name, size := range tt.want {
+				rule, ok := list.byName(name)
+				if !ok || rule.MaxSize != size {
+					t.Errorf("%s: got %v (%v), want max size %d", name, rule.MaxSize, ok, size)
+				}
+			}
+			if got := list.MaxSize(); got != tt.maxSize {
+				t.Errorf("MaxSize = %d, want %d", got, tt.maxSize)
+			}
+		})
+	}
+}
+
+func TestParseAllowListInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		" , ",
+		"jpeg",
+		"bmp:5MB",
+		"jpeg:5MB,jpeg:1MB",
+		"jpeg:0",
+		"jpeg:-1",
+		"jpeg:5GB",
+		"jpeg:lots",
+		"jpeg:2000000MB",
+	}
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := ParseAllowList(spec); err == nil {
+				t.Fatalf("ParseAllowList(%q) succeeded", spec)
+			}
+		})
+	}
+}
+
+func TestForExtension(t *testing.T) {
+	list, err := ParseAllowList("jpeg:5MB,pdf:10MB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ext  string
+		want string
+		err  error
+	}{
+		{".jpg", "jpeg", nil},
+		{".JPEG", "jpeg", nil},
+		{".pdf", "pdf", nil},
+		{".png", "", ErrNotAllowed},
+		{".exe", "", ErrNotAllowed},
+		{"", "", ErrNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			rule, err := list.ForExtension(tt.ext)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if rule.Name != tt.want {
+				t.Errorf("got %q, want %q", rule.Name, tt.want)
+			}
+		})
+	}
+}
+
+// Uploads are identified by sniffing their content with
+// http.DetectContentType, so every known type must be what it reports for
+// such a file.
+func TestByMIMESniffed(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"jpeg", "\xff\xd8\xff\xe0\x00\x10JFIF\x00"},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"},
+		{"gif", "GIF89a\x01\x00\x01\x00"},
+		{"webp", "RIFF\x24\x00\x00\x00WEBPVP8 "},
+		{"pdf", "%PDF-1.7\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sniffed := http.DetectContentType([]byte(tt.header))
+			got, ok := ByMIME(sniffed)
+			if !ok || got.Name != tt.name {
+				t.Fatalf("sniffed %q: got %q (%v), want %q", sniffed, got.Name, ok, tt.name)
+			}
+		})
+	}
+	for _, mimeType := range []string{"text/plain; charset=utf-8", "application/octet-stream", "image/svg+xml"} {
+		if _, ok := ByMIME(mimeType); ok {
+			t.Errorf("ByMIME(%q) found a type", mimeType)
+		}
+	}
+}
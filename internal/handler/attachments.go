@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"mastery-project/internal/filetype"
+	"mastery-project/internal/model"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (h *ItemHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.ItemService.Attachments(r.Context(), id, user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, attachments)
+}
+
+// AddAttachment stores the multipart "file" part, or the completed resumable
+// upload named by "upload_id", and appends it to the item.
+func (h *ItemHandler) AddAttachment(w http.ResponseWriter, r *http.Request) {
+	itemID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, http.StatusNotFound, "item not found")
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.parseUploadForm(w, r) {
+		return
+	}
+
+	upload, err := h.formUpload(r, user.ID, false)
+	if err != nil {
+		h.uploadError(w, err)
+		return
+	}
+	if upload == nil {
+		h.JSON(w, http.StatusBadRequest, "file is required")
+		return
+	}
+
+	attachment := model.Attachment{
+		ItemID:       itemID,
+		OriginalName: upload.OriginalName,
+		FileName:     upload.Name,
+		Size:         upload.Size,
+		MimeType:     upload.MimeType,
+		Checksum:     upload.Checksum,
+	}
+	if err := h.ItemService.AddAttachment(r.Context(), user.ID, &attachment); err != nil {
+		h.discardUpload(r.Context(), upload)
+		h.itemError(w, err)
+		return
+	}
+	h.finishUpload(r.Context(), upload)
+
+	h.JSON(w, http.StatusCreated, attachment)
+}
+
+func (h *ItemHandler) ReorderAttachments(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.ReorderAttachmentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	attachments, err := h.ItemService.ReorderAttachments(r.Context(), id, user.ID, req.IDs)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, attachments)
+}
+
+func (h *ItemHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+	attachmentID := chi.URLParam(r, "attachmentID")
+
+	if _, err := uuid.Parse(attachmentID); err != nil {
+		h.JSON(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	attachment, err := h.ItemService.DeleteAttachment(r.Context(), id, user.ID, attachmentID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.releaseUpload(r.Context(), attachment.FileName)
+
+	h.JSON(w, http.StatusOK, map[string]string{"message": "attachment deleted"})
+}
+
+// AttachmentFile serves an attachment's file to anyone who can see the item.
+func (h *ItemHandler) AttachmentFile(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+	attachmentID := chi.URLParam(r, "attachmentID")
+
+	if _, err := uuid.Parse(attachmentID); err != nil {
+		h.JSON(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	attachment, err := h.ItemService.AttachmentFile(r.Context(), id, user.ID, attachmentID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", cacheControl("private", h.fileMaxAge))
+	h.serveAttachment(w, r, attachment)
+}
+
+// PublicAttachmentFile serves an attachment of an unlisted or public item
+// without authentication.
+func (h *ItemHandler) PublicAttachmentFile(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+	attachmentID := chi.URLParam(r, "attachmentID")
+
+	if _, err := uuid.Parse(attachmentID); err != nil {
+		h.JSON(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+
+	attachment, err := h.ItemService.PublicAttachmentFile(r.Context(), id, attachmentID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", cacheControl("public", h.publicMaxAge))
+	h.serveAttachment(w, r, attachment)
+}
+
+// serveAttachment serves the file under the name it was uploaded with. Types
+// such as PDF are sent as downloads rather than rendered by the browser.
+func (h *ItemHandler) serveAttachment(w http.ResponseWriter, r *http.Request, attachment *model.Attachment) {
+	dispositionType := "inline"
+	if t, ok := filetype.ByMIME(attachment.MimeType); ok && t.Download {
+		dispositionType = "attachment"
+	}
+	if disposition := mime.FormatMediaType(dispositionType, map[string]string{"filename": attachment.OriginalName}); disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+	h.serveUpload(w, r, attachment.FileName, attachment.Checksum)
+}
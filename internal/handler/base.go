@@ -2,6 +2,8 @@ package handler
 
 import (
 	"encoding/json"
+	"mastery-project/internal/middleware"
+	"mastery-project/internal/model"
 	"net/http"
 )
 
@@ -22,3 +24,13 @@ func (h Handler) JSON(w http.ResponseWriter, code int, payload interface{}) {
 		return
 	}
 }
+
+// currentUser returns the authenticated user, answering 401 when there is none.
+func (h Handler) currentUser(w http.ResponseWriter, r *http.Request) (*model.User, bool) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		h.JSON(w, http.StatusUnauthorized, "unauthorized")
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"net/http"
+	"sort"
+)
+
+// Bulk runs create, update and delete operations from one JSON body. In the
+// default atomic mode nothing is applied unless every operation succeeds; in
+// best_effort mode each operation reports its own status. All creates run
+// first, loaded together, and then the updates and deletes in request order,
+// so an operation cannot depend on an earlier create in the same batch.
+// Results are listed by their index in the request.
+func (h *ItemHandler) Bulk(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.BulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = "atomic"
+	}
+	atomic := req.Mode == "atomic"
+
+	if err := h.ItemService.CheckBulkSize(len(req.Operations)); err != nil {
+		h.itemError(w, err)
+		return
+	}
+
+	response := model.BulkResponse{Mode: req.Mode}
+	var valid []model.BulkOperation
+	for i, op := range req.Operations {
+		op.Index = i
+		if err := validateBulkOperation(user, op); err != nil {
+			response.Results = append(response.Results, model.BulkResult{
+				Index:  i,
+				Op:     op.Op,
+				ID:     op.ID,
+				Status: http.StatusBadRequest,
+				Error:  err.Error(),
+			})
+			continue
+		}
+		valid = append(valid, op)
+	}
+
+	if atomic && len(response.Results) > 0 {
+		h.JSON(w, http.StatusBadRequest, abortBulk(response, valid))
+		return
+	}
+
+	if len(valid) > 0 {
+		outcome, err := h.ItemService.Bulk(r.Context(), user.ID, valid, atomic)
+		if outcome == nil {
+			h.itemError(w, err)
+			return
+		}
+		for _, result := range outcome.Results {
+			response.Results = append(response.Results, bulkResult(result))
+		}
+		if err != nil {
+			// an operation that failed on its own aborts the batch with 422;
+			// anything else, such as a lost connection, is a server error
+			if atomic && itemErrorStatus(err) != http.StatusInternalServerError {
+				h.JSON(w, http.StatusUnprocessableEntity, abortBulk(response, valid))
+				return
+			}
+			h.itemError(w, err)
+			return
+		}
+		for _, file := range outcome.Released {
+			h.releaseUpload(r.Context(), file)
+		}
+	}
+
+	sort.Slice(response.Results, func(i, j int) bool {
+		return response.Results[i].Index < response.Results[j].Index
+	})
+	for _, result := range response.Results {
+		if result.Error == "" {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	h.JSON(w, http.StatusOK, response)
+}
+
+func validateBulkOperation(user *model.User, op model.BulkOperation) error {
+	if err := validate.Struct(op); err != nil {
+		return err
+	}
+	switch op.Op {
+	case "create":
+		if op.Title == nil || op.Description == nil {
+			return errors.New("title and description are required")
+		}
+		// bulk creates are private, like the column default they are stored with
+		return validate.Struct(model.Item{UserID: user.ID, Title: *op.Title, Description: *op.Description, Visibility: model.VisibilityPrivate})
+	case "update":
+		if op.Title == nil && op.Description == nil {
+			return errors.New("nothing to update")
+		}
+		return validate.Struct(model.UpdateItem{Title: op.Title, Description: op.Description})
+	}
+	return nil
+}
+
+func bulkResult(result repository.BulkOpResult) model.BulkResult {
+	out := model.BulkResult{
+		Index:   result.Index,
+		Op:      result.Op,
+		ID:      result.ID,
+		Version: result.Version,
+		Status:  http.StatusOK,
+	}
+	if result.Op == "create" {
+		out.Status = http.StatusCreated
+	}
+	if result.Err != nil {
+		out.Status = itemErrorStatus(result.Err)
+		out.Error = result.Err.Error()
+		out.Version = 0
+	}
+	return out
+}
+
+// abortBulk reports an atomic batch that was not applied: operations that did
+// not fail themselves are marked 424 Failed Dependency.
+func abortBulk(response model.BulkResponse, ops []model.BulkOperation) model.BulkResponse {
+	failed := make(map[int]bool, len(response.Results))
+	var results []model.BulkResult
+	for _, result := range response.Results {
+		if result.Error != "" {
+			failed[result.Index] = true
+			results = append(results, result)
+		}
+	}
+	for _, op := range ops {
+		if !failed[op.Index] {
+			results = append(results, model.BulkResult{
+				Index:  op.Index,
+				Op:     op.Op,
+				ID:     op.ID,
+				Status: http.StatusFailedDependency,
+				Error:  "not applied: another operation in the batch failed",
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+
+	response.Results = results
+	response.Succeeded = 0
+	response.Failed = len(results)
+	return response
+}
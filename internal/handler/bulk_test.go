@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"mastery-project/internal/model"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateBulkOperation(t *testing.T) {
+	user := &model.User{ID: uuid.New()}
+	id := uuid.New()
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		op    model.BulkOperation
+		valid bool
+	}{
+		{"create", model.BulkOperation{Op: "create", Title: str("Title"), Description: str("Description")}, true},
+		{"create without description", model.BulkOperation{Op: "create", Title: str("Title")}, false},
+		{"create with empty title", model.BulkOperation{Op: "create", Title: str(""), Description: str("Description")}, false},
+		{"create with long title", model.BulkOperation{Op: "create", Title: str(strings.Repeat("a", 256)), Description: str("Description")}, false},
+		{"update", model.BulkOperation{Op: "update", ID: &id, Title: str("Title")}, true},
+		{"update without id", model.BulkOperation{Op: "update", Title: str("Title")}, false},
+		{"update without changes", model.BulkOperation{Op: "update", ID: &id}, false},
+		{"delete", model.BulkOperation{Op: "delete", ID: &id}, true},
+		{"unknown op", model.BulkOperation{Op: "upsert", ID: &id}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBulkOperation(user, tt.op)
+			if (err == nil) != tt.valid {
+				t.Fatalf("got error %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
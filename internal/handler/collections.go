@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mastery-project/internal/config"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"mastery-project/internal/service"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CollectionHandler struct {
+	Handler
+	CollectionService *service.CollectionService
+}
+
+func NewCollectionHandler(cfg *config.Config, collectionService *service.CollectionService) *CollectionHandler {
+	return &CollectionHandler{
+		Handler:           NewHandler(cfg.ENV),
+		CollectionService: collectionService,
+	}
+}
+
+// List returns the caller's collections. ?parent_id= limits the list to that
+// collection's children and ?top_level=true to collections without a parent.
+func (h *CollectionHandler) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var parentID *uuid.UUID
+	if raw := r.URL.Query().Get("parent_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			h.JSON(w, http.StatusBadRequest, "invalid parent_id")
+			return
+		}
+		parentID = &id
+	}
+	topLevel := r.URL.Query().Get("top_level") == "true"
+
+	collections, err := h.CollectionService.List(r.Context(), user.ID, parentID, topLevel)
+	if err != nil {
+		h.collectionError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, collections)
+}
+
+func (h *CollectionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CreateCollection
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	collection, err := h.CollectionService.Create(r.Context(), user.ID, req)
+	if err != nil {
+		h.collectionError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusCreated, collection)
+}
+
+// Get returns the collection with its direct children.
+func (h *CollectionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.collectionID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	collection, err := h.CollectionService.Get(r.Context(), id.String(), user.ID)
+	if err != nil {
+		h.collectionError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, collection)
+}
+
+// Update renames, re-describes, moves (parent_id) or changes the cover
+// (cover_item_id) of a collection; null parent_id moves it to the top level.
+func (h *CollectionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.collectionID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateCollection
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	collection, err := h.CollectionService.Update(r.Context(), id, user.ID, req)
+	if err != nil {
+		h.collectionError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, collection)
+}
+
+// Delete removes the collection and its sub-collections. The items in them are
+// not deleted.
+func (h *CollectionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.collectionID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.CollectionService.Delete(r.Context(), id.String(), user.ID); err != nil {
+		h.collectionError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "collection deleted"})
+}
+
+// Items lists the collection's items, paged with ?limit= and ?offset=.
+func (h *CollectionHandler) Items(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.collectionID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.CollectionService.Items(r.Context(), id.String(), user.ID, limit, offset)
+	if err != nil {
+		h.collectionError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, page)
+}
+
+func (h *CollectionHandler) AddItems(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.collectionID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CollectionItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.CollectionService.AddItems(r.Context(), id, user.ID, req.ItemIDs); err != nil {
+		h.collectionError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "items added"})
+}
+
+func (h *CollectionHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.collectionID(w, r)
+	if !ok {
+		return
+	}
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemID"))
+	if err != nil {
+		h.JSON(w, http.StatusNotFound, repository.ErrNotInCollection.Error())
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.CollectionService.RemoveItem(r.Context(), id, user.ID, itemID); err != nil {
+		h.collectionError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "item removed"})
+}
+
+// MoveItems moves items from this collection into the collection named by "to".
+func (h *CollectionHandler) MoveItems(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.collectionID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.MoveItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.CollectionService.MoveItems(r.Context(), id, user.ID, req); err != nil {
+		h.collectionError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "items moved"})
+}
+
+func (h *CollectionHandler) collectionID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, http.StatusNotFound, repository.ErrCollectionNotFound.Error())
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *CollectionHandler) collectionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrCollectionNotFound),
+		errors.Is(err, repository.ErrNotInCollection):
+		h.JSON(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repository.ErrParentNotFound),
+		errors.Is(err, repository.ErrItemNotFound):
+		h.JSON(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, repository.ErrCollectionCycle):
+		h.JSON(w, http.StatusConflict, err.Error())
+	default:
+		h.JSON(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ListComments returns the item's top-level comments with their replies,
+// paged with ?limit= and ?offset=.
+func (h *ItemHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.ItemService.Comments(r.Context(), id, user.ID, limit, offset)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, page)
+}
+
+// AddComment posts a comment; with parent_id it replies to a top-level comment.
+func (h *ItemHandler) AddComment(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CreateComment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comment, err := h.ItemService.AddComment(r.Context(), id, user.ID, req)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusCreated, comment)
+}
+
+func (h *ItemHandler) EditComment(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+	commentID, ok := h.commentID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateComment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comment, err := h.ItemService.EditComment(r.Context(), id, commentID, user.ID, req)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, comment)
+}
+
+func (h *ItemHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+	commentID, ok := h.commentID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ItemService.DeleteComment(r.Context(), id, commentID, user.ID); err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "comment deleted"})
+}
+
+func (h *ItemHandler) commentID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	commentID := chi.URLParam(r, "commentID")
+	if _, err := uuid.Parse(commentID); err != nil {
+		h.JSON(w, http.StatusNotFound, repository.ErrCommentNotFound.Error())
+		return "", false
+	}
+	return commentID, true
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"mastery-project/internal/model"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// splitETags splits an If-Match / If-None-Match header into its entity tags.
+func splitETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// versionFromETag parses a strong item ETag, with or without the digest
+// itemETag appends, back into its version.
+func versionFromETag(tag string) (int, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	raw, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+	version, err := strconv.Atoi(raw)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// notModified reports whether If-None-Match matches etag, using the weak
+// comparison RFC 9110 prescribes for that header.
+func notModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range splitETags(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// itemETag is the strong validator of a single item as the caller sees it: its
+// version, which If-Match is checked against, followed by a digest of the
+// fields that change without a new version, which are the caller's permission,
+// favorite flag and position, and the rendered image variants.
+func itemETag(item *model.Item) string {
+	hash := sha256.New()
+	hash.Write([]byte(item.Permission))
+	hash.Write([]byte(strconv.FormatBool(item.Favorite)))
+	hash.Write([]byte(item.Position))
+	for _, name := range slices.Sorted(maps.Keys(item.ImageVariants)) {
+		hash.Write([]byte(name))
+	}
+	return `"` + strconv.Itoa(item.Version) + "-" + hex.EncodeToString(hash.Sum(nil)[:8]) + `"`
+}
+
+// listETag derives a weak validator for a list of items from their ids,
+// versions, rendered image variants and the caller's permission, favorite flag
+// and position on each.
+func listETag(items []model.Item) string {
+	hash := sha256.New()
+	for _, item := range items {
+		hash.Write(item.ID[:])
+		hash.Write([]byte(strconv.Itoa(item.Version)))
+		hash.Write([]byte(item.Permission))
+		hash.Write([]byte(strconv.FormatBool(item.Favorite)))
+		hash.Write([]byte(item.Position))
+		for _, name := range slices.Sorted(maps.Keys(item.ImageVariants)) {
+			hash.Write([]byte(name))
+		}
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// expectedVersion resolves the If-Match header into the version a write must find
+// the item at; 0 means the write is unconditional. It writes the error response
+// and returns false when the precondition cannot be met.
+func (h *ItemHandler) expectedVersion(w http.ResponseWriter, r *http.Request, id string, userID uuid.UUID) (int, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if h.requireIfMatch {
+			h.JSON(w, http.StatusPreconditionRequired, "If-Match header is required")
+			return 0, false
+		}
+		return 0, true
+	}
+
+	tags := splitETags(header)
+	if len(tags) == 1 && tags[0] != "*" {
+		version, ok := versionFromETag(tags[0])
+		if !ok {
+			h.JSON(w, http.StatusPreconditionFailed, "item has been modified")
+			return 0, false
+		}
+		return version, true
+	}
+
+	// "*" or several tags: check against the current version and pin the write to it
+	item, err := h.ItemService.GetOne(r.Context(), id, userID)
+	if err != nil {
+		h.itemError(w, err)
+		return 0, false
+	}
+	for _, tag := range tags {
+		if version, _ := versionFromETag(tag); tag == "*" || version == item.Version {
+			return item.Version, true
+		}
+	}
+	h.JSON(w, http.StatusPreconditionFailed, "item has been modified")
+	return 0, false
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"mastery-project/internal/model"
+	"testing"
+)
+
+func TestItemETag(t *testing.T) {
+	base := model.Item{Version: 3, Permission: model.PermissionOwner}
+	tests := []struct {
+		name   string
+		change func(*model.Item)
+		same   bool
+	}{
+		{"unchanged", func(*model.Item) {}, true},
+		{"new version", func(item *model.Item) { item.Version++ }, false},
+		{"permission", func(item *model.Item) { item.Permission = model.PermissionEditor }, false},
+		{"favorite", func(item *model.Item) { item.Favorite = true }, false},
+		{"position", func(item *model.Item) { item.Position = "a0" }, false},
+		{"variant rendered", func(item *model.Item) { item.ImageVariants = map[string]string{"thumb": "/x"} }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := base
+			tt.change(&item)
+			if got := itemETag(&item) == itemETag(&base); got != tt.same {
+				t.Fatalf("%s and %s: same = %v, want %v", itemETag(&item), itemETag(&base), got, tt.same)
+			}
+			version, ok := versionFromETag(itemETag(&item))
+			if !ok || version != item.Version {
+				t.Errorf("versionFromETag = %d, %v; want %d", version, ok, item.Version)
+			}
+		})
+	}
+}
+
+func TestVersionFromETag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		version int
+		ok      bool
+	}{
+		{`"7"`, 7, true},
+		{`"7-0123456789abcdef"`, 7, true},
+		{`W/"7"`, 0, false},
+		{`7`, 0, false},
+		{`"0"`, 0, false},
+		{`"-1"`, 0, false},
+		{`"abc"`, 0, false},
+		{`""`, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			version, ok := versionFromETag(tt.tag)
+			if version != tt.version || ok != tt.ok {
+				t.Fatalf("got %d, %v; want %d, %v", version, ok, tt.version, tt.ok)
+			}
+		})
+	}
+}
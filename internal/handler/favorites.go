@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"mastery-project/internal/model"
+	"net/http"
+)
+
+func (h *ItemHandler) Favorite(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ItemService.Favorite(r.Context(), id, user.ID); err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "item added to favorites"})
+}
+
+func (h *ItemHandler) Unfavorite(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ItemService.Unfavorite(r.Context(), id, user.ID); err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "item removed from favorites"})
+}
+
+// Move places the item directly after ("after") or before ("before") another
+// item of the caller's list, or at the top when neither is given, and returns
+// its new position key.
+func (h *ItemHandler) Move(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.MoveItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	position, err := h.ItemService.MoveItem(r.Context(), id, user.ID, req)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"position": position})
+}
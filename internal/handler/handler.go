@@ -3,18 +3,35 @@ package handler
 import (
 	"mastery-project/internal/config"
 	"mastery-project/internal/service"
+	"mastery-project/internal/storage"
 )
 
 type Handlers struct {
-	Health *HealthHandler
-	Auth   *AuthHandler
-	Item   *ItemHandler
+	Health     *HealthHandler
+	Auth       *AuthHandler
+	Item       *ItemHandler
+	Import     *ImportHandler
+	Collection *CollectionHandler
+	Upload     *UploadHandler
 }
 
-func NewHandlers(cfg *config.Config, service *service.Services) *Handlers {
-	return &Handlers{
-		Health: NewHealthHandler(cfg),
-		Auth:   NewAuthHandler(cfg, service.Auth),
-		Item:   NewItemHandler(cfg, service.Item),
+func NewHandlers(cfg *config.Config, service *service.Services, blobs storage.Blob) (*Handlers, error) {
+	itemHandler, err := NewItemHandler(cfg, service.Item, service.Variant, service.Upload, blobs)
+	if err != nil {
+		return nil, err
 	}
+	return &Handlers{
+		Health:     NewHealthHandler(cfg),
+		Auth:       NewAuthHandler(cfg, service.Auth),
+		Item:       itemHandler,
+		Import:     NewImportHandler(cfg, service.Import),
+		Collection: NewCollectionHandler(cfg, service.Collection),
+		Upload:     NewUploadHandler(cfg, service.Upload),
+	}, nil
+}
+
+// MaxBody is the largest request body any handler accepts: an upload form
+// with the largest allowed file, or an import file.
+func (h *Handlers) MaxBody() int64 {
+	return max(h.Item.maxUploadBody(), h.Import.maxBytes)
 }
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"errors"
+	"mastery-project/internal/imaging"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ReplaceImage swaps the item's image for the uploaded "file" part or "upload_id"
+// (see formUpload). The new file
+// is written first and the old one is only removed after the row points at the
+// new one, so a failure at any step leaves the item with a working image.
+func (h *ItemHandler) ReplaceImage(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.parseUploadForm(w, r) {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	expected, ok := h.expectedVersion(w, r, id, user.ID)
+	if !ok {
+		return
+	}
+
+	upload, err := h.formUpload(r, user.ID, true)
+	if err != nil {
+		h.uploadError(w, err)
+		return
+	}
+	if upload == nil {
+		h.JSON(w, http.StatusBadRequest, "file is required")
+		return
+	}
+
+	previous, item, err := h.ItemService.ReplaceImage(r.Context(), id, user.ID, expected, upload.Name, upload.Checksum, upload.Size)
+	if err != nil {
+		h.discardUpload(r.Context(), upload)
+		h.itemError(w, err)
+		return
+	}
+	h.finishUpload(r.Context(), upload)
+	h.releaseUpload(r.Context(), previous)
+	h.variants.Enqueue(upload.Name)
+
+	w.Header().Set("ETag", item.ETag)
+	h.JSON(w, http.StatusOK, item)
+}
+
+func (h *ItemHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	expected, ok := h.expectedVersion(w, r, id, user.ID)
+	if !ok {
+		return
+	}
+
+	previous, item, err := h.ItemService.ReplaceImage(r.Context(), id, user.ID, expected, "", "", 0)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.releaseUpload(r.Context(), previous)
+
+	w.Header().Set("ETag", item.ETag)
+	h.JSON(w, http.StatusOK, item)
+}
+
+// Image serves the item's image to anyone who can see the item. ?size= picks
+// a variant; see serveImage.
+func (h *ItemHandler) Image(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.ItemService.ItemImage(r.Context(), id, user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", cacheControl("private", h.fileMaxAge))
+	h.serveImage(w, r, item)
+}
+
+// ImageURL hands out a time-limited signed URL for the item's image, for
+// places such as <img> tags that cannot send the session. The signature covers
+// the stored file, so replacing or deleting the image invalidates it.
+func (h *ItemHandler) ImageURL(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.ItemService.ItemImage(r.Context(), id, user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+
+	query := h.signer.Query(itemImagePayload(item), h.imageURLTTL)
+	expires, _ := strconv.ParseInt(query.Get("exp"), 10, 64)
+	h.JSON(w, http.StatusOK, map[string]any{
+		"url":        "/images/" + item.ID.String() + "?" + query.Encode(),
+		"expires_at": time.Unix(expires, 0).UTC(),
+	})
+}
+
+// SignedImage serves an image through a URL from ImageURL. Unknown items and
+// bad signatures get the same answer, so the URL reveals nothing without a
+// valid signature.
+func (h *ItemHandler) SignedImage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, err := uuid.Parse(id); err != nil {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+
+	item, err := h.ItemService.SignedImage(r.Context(), id)
+	if err != nil && itemErrorStatus(err) != http.StatusNotFound {
+		http.Error(w, "failed to load image", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || !h.signer.Verify(itemImagePayload(item), r.URL.Query()) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Cache-Control", cacheControl("private", signedMaxAge(r.URL.Query())))
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	h.serveImage(w, r, item)
+}
+
+// PublicImage serves the image of an unlisted or public item without
+// authentication.
+func (h *ItemHandler) PublicImage(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.ItemService.PublicImage(r.Context(), id)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", cacheControl("public", h.publicMaxAge))
+	h.serveImage(w, r, item)
+}
+
+// serveImage serves the item's image, or the variant named by ?size=. Until a
+// variant has been rendered the original is served in its place.
+func (h *ItemHandler) serveImage(w http.ResponseWriter, r *http.Request, item *model.Item) {
+	size := r.URL.Query().Get("size")
+	if size == "" || size == imaging.Original {
+		h.serveUpload(w, r, item.FilePath, item.FileChecksum)
+		return
+	}
+
+	variant, err := h.variants.Variant(r.Context(), item.FilePath, size)
+	switch {
+	case err == nil:
+		h.serveUpload(w, r, variant.FileName, variant.Checksum)
+	case errors.Is(err, repository.ErrImageVariantNotFound):
+		h.serveUpload(w, r, item.FilePath, item.FileChecksum)
+	default:
+		h.itemError(w, err)
+	}
+}
+
+func itemImagePayload(item *model.Item) string {
+	return "item-image:" + item.ID.String() + ":" + item.FilePath
+}
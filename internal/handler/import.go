@@ -0,0 +1,388 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mastery-project/internal/config"
+	"mastery-project/internal/middleware"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"mastery-project/internal/service"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxReportedErrors caps the per-line errors returned in one import report.
+const maxReportedErrors = 1000
+
+var exportColumns = []string{"id", "title", "description", "file_path", "version", "created_at", "updated_at"}
+
+var formatContentTypes = map[string]string{
+	"csv":    "text/csv",
+	"json":   "application/json",
+	"ndjson": "application/x-ndjson",
+}
+
+type ImportHandler struct {
+	Handler
+	ImportService *service.ImportService
+	maxBytes      int64
+}
+
+func NewImportHandler(cfg *config.Config, importService *service.ImportService) *ImportHandler {
+	return &ImportHandler{
+		Handler:       NewHandler(cfg.ENV),
+		ImportService: importService,
+		maxBytes:      int64(cfg.Items.ImportMaxBytes),
+	}
+}
+
+// Export streams the user's items as csv, json or ndjson (?format=, default json)
+// directly from the database cursor.
+func (h *ImportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		h.JSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	contentType, ok := formatContentTypes[format]
+	if !ok {
+		h.JSON(w, http.StatusBadRequest, "format must be csv, json or ndjson")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="items.%s"`, format))
+
+	var err error
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err = cw.Write(exportColumns); err == nil {
+			err = h.ImportService.Export(r.Context(), user.ID, func(item *model.Item) error {
+				return cw.Write([]string{
+					item.ID.String(),
+					item.Title,
+					item.Description,
+					item.FilePath,
+					strconv.Itoa(item.Version),
+					item.CreatedAt.Format(time.RFC3339),
+					item.UpdateAt.Format(time.RFC3339),
+				})
+			})
+		}
+		cw.Flush()
+		if err == nil {
+			err = cw.Error()
+		}
+	case "json":
+		first := true
+		if _, err = io.WriteString(w, "["); err == nil {
+			err = h.ImportService.Export(r.Context(), user.ID, func(item *model.Item) error {
+				data, err := json.Marshal(item)
+				if err != nil {
+					return err
+				}
+				if !first {
+					if _, err := io.WriteString(w, ","); err != nil {
+						return err
+					}
+				}
+				first = false
+				_, err = w.Write(data)
+				return err
+			})
+		}
+		if err == nil {
+			_, err = io.WriteString(w, "]\n")
+		}
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		err = h.ImportService.Export(r.Context(), user.ID, func(item *model.Item) error {
+			return enc.Encode(item)
+		})
+	}
+
+	if err != nil {
+		// the status line is already out; cut the response short so the client sees a truncated body
+		slog.Error("export failed", "user", user.ID, "err", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// Import creates items from a csv, json or ndjson body. The format comes from
+// ?format= or the Content-Type. Every row is validated like a regular create and
+// failures are reported per line; ?dry_run=true only validates. Files with more
+// rows than ITEMS_IMPORT_ASYNC_ROWS are imported by a background job and answered
+// with 202 and the job's status URL.
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		h.JSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	format := importFormat(r)
+	if format == "" {
+		h.JSON(w, http.StatusUnsupportedMediaType, "format must be csv, json or ndjson")
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	body := http.MaxBytesReader(w, r.Body, h.maxBytes)
+	rows, parseErrors, err := parseImport(format, body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.JSON(w, http.StatusRequestEntityTooLarge, "import file too large")
+			return
+		}
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	total := len(rows) + len(parseErrors)
+	invalid := parseErrors
+	var valid []model.ImportRow
+	for _, row := range rows {
+		if err := validateImportRow(user, row); err != nil {
+			invalid = append(invalid, model.ImportError{Line: row.Line, Error: err.Error()})
+			continue
+		}
+		valid = append(valid, row)
+	}
+	if len(valid) > 0 {
+		if err := h.ImportService.CheckMetadata(r.Context(), user.ID); err != nil {
+			if !errors.Is(err, service.ErrMetadataInvalid) {
+				h.JSON(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			for _, row := range valid {
+				invalid = append(invalid, model.ImportError{Line: row.Line, Error: err.Error()})
+			}
+			valid = nil
+		}
+	}
+
+	report := model.ImportReport{
+		DryRun: dryRun,
+		Total:  total,
+		Failed: len(invalid),
+		Errors: invalid,
+	}
+
+	if !dryRun && len(valid) > 0 {
+		if h.ImportService.RunsAsync(total) {
+			job, err := h.ImportService.StartJob(r.Context(), user.ID, format, valid, invalid, total)
+			if err != nil {
+				h.JSON(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			w.Header().Set("Location", "/api/v1/items/import/"+job.ID.String())
+			h.JSON(w, http.StatusAccepted, job)
+			return
+		}
+
+		imported, failures, err := h.ImportService.Import(r.Context(), user.ID, valid)
+		if err != nil {
+			h.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		report.Imported = imported
+		report.Failed += len(failures)
+		report.Errors = append(report.Errors, failures...)
+	}
+
+	if report.Errors == nil {
+		report.Errors = []model.ImportError{}
+	}
+	if len(report.Errors) > maxReportedErrors {
+		report.Errors = report.Errors[:maxReportedErrors]
+	}
+	h.JSON(w, http.StatusOK, report)
+}
+
+func (h *ImportHandler) Job(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		h.JSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	job, err := h.ImportService.Job(r.Context(), user.ID, chi.URLParam(r, "jobID"))
+	if err != nil {
+		if errors.Is(err, repository.ErrImportJobNotFound) {
+			h.JSON(w, http.StatusNotFound, err.Error())
+			return
+		}
+		h.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.JSON(w, http.StatusOK, job)
+}
+
+// validateImportRow checks a row as the item it becomes; imported items are
+// private, like the column default they are stored with.
+func validateImportRow(user *model.User, row model.ImportRow) error {
+	return validate.Struct(model.Item{UserID: user.ID, Title: row.Title, Description: row.Description, Visibility: model.VisibilityPrivate})
+}
+
+func importFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, ok := formatContentTypes[format]; ok {
+			return format
+		}
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	for format, contentType := range formatContentTypes {
+		if mediaType == contentType {
+			return format
+		}
+	}
+	return ""
+}
+
+// importRecord is how a row looks in json and ndjson imports.
+type importRecord struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// parseImport reads every row of the file. Rows that cannot be decoded are
+// returned as per-line errors; an error is only returned when the file as a
+// whole is unreadable.
+func parseImport(format string, body io.Reader) ([]model.ImportRow, []model.ImportError, error) {
+	switch format {
+	case "csv":
+		return parseCSV(body)
+	case "json":
+		return parseJSON(body)
+	default:
+		return parseNDJSON(body)
+	}
+}
+
+// parseCSV expects a header row naming at least the title and description
+// columns, so files produced by the export can be imported again.
+func parseCSV(body io.Reader) ([]model.ImportRow, []model.ImportError, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+	titleCol, descriptionCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "title":
+			titleCol = i
+		case "description":
+			descriptionCol = i
+		}
+	}
+	if titleCol < 0 || descriptionCol < 0 {
+		return nil, nil, errors.New("csv header must contain title and description columns")
+	}
+
+	var rows []model.ImportRow
+	var errs []model.ImportError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				errs = append(errs, model.ImportError{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) <= max(titleCol, descriptionCol) {
+			errs = append(errs, model.ImportError{Line: line, Error: "missing columns"})
+			continue
+		}
+		rows = append(rows, model.ImportRow{
+			Line:        line,
+			Title:       record[titleCol],
+			Description: record[descriptionCol],
+		})
+	}
+	return rows, errs, nil
+}
+
+// parseJSON reads an array of objects; Line is the element's 1-based position.
+func parseJSON(body io.Reader) ([]model.ImportRow, []model.ImportError, error) {
+	dec := json.NewDecoder(body)
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid json: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, nil, errors.New("json import must be an array of items")
+	}
+
+	var rows []model.ImportRow
+	var errs []model.ImportError
+	for line := 1; dec.More(); line++ {
+		var record importRecord
+		if err := dec.Decode(&record); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				errs = append(errs, model.ImportError{Line: line, Error: err.Error()})
+				continue
+			}
+			return nil, nil, fmt.Errorf("invalid json at element %d: %w", line, err)
+		}
+		rows = append(rows, model.ImportRow{Line: line, Title: record.Title, Description: record.Description})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, fmt.Errorf("invalid json: %w", err)
+	}
+	return rows, errs, nil
+}
+
+func parseNDJSON(body io.Reader) ([]model.ImportRow, []model.ImportError, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	var rows []model.ImportRow
+	var errs []model.ImportError
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record importRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			errs = append(errs, model.ImportError{Line: line, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, model.ImportRow{Line: line, Title: record.Title, Description: record.Description})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return rows, errs, nil
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"mastery-project/internal/model"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestImportRowsValidate(t *testing.T) {
+	user := &model.User{ID: uuid.New()}
+	tests := []struct {
+		format  string
+		body    string
+		valid   int
+		invalid int
+	}{
+		{"csv", "title,description\nFirst,One\nSecond,Two\n", 2, 0},
+		{"csv", "title,description\nFirst,One\n,Missing title\n", 1, 1},
+		{"json", `[{"title":"First","description":"One"},{"title":"Second","description":"Two"}]`, 2, 0},
+		{"ndjson", "{\"title\":\"First\",\"description\":\"One\"}\n{\"title\":\"" + strings.Repeat("a", 256) + "\",\"description\":\"Two\"}\n", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			rows, parseErrors, err := parseImport(tt.format, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("parseImport: %v", err)
+			}
+			valid, invalid := 0, len(parseErrors)
+			for _, row := range rows {
+				if err := validateImportRow(user, row); err != nil {
+					invalid++
+					continue
+				}
+				valid++
+			}
+			if valid != tt.valid || invalid != tt.invalid {
+				t.Fatalf("got %d valid and %d invalid rows, want %d and %d", valid, invalid, tt.valid, tt.invalid)
+			}
+		})
+	}
+}
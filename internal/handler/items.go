@@ -2,241 +2,382 @@ package handler
 
 import (
 	"crypto/rand"
-	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"io"
+	"log/slog"
 	"mastery-project/internal/config"
+	"mastery-project/internal/filetype"
+	"mastery-project/internal/imaging"
 	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
 	"mastery-project/internal/service"
-	"mime/multipart"
+	"mastery-project/internal/signature"
+	"mastery-project/internal/storage"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type ItemHandler struct {
 	Handler
-	ItemService *service.ItemService
+	ItemService    *service.ItemService
+	variants       *service.VariantService
+	blobs          storage.Blob
+	requireIfMatch bool
+	signer         *signature.Signer
+	linkImageTTL   time.Duration
+	imageURLTTL    time.Duration
+	fileMaxAge     time.Duration
+	publicMaxAge   time.Duration
+	imageLimits    imaging.Limits
+	imageMetadata  bool
+	uploads        *filetype.AllowList
+	uploadService  *service.UploadService
 }
 
-func NewItemHandler(cfg *config.Config, itemService *service.ItemService) *ItemHandler {
-	return &ItemHandler{
-		Handler:     NewHandler(cfg.ENV),
-		ItemService: itemService}
-}
-
-func (h *ItemHandler) Create(w http.ResponseWriter, r *http.Request) {
-	const maxUploadSize = 5 << 20 // 5MB
-	const uploadDir = "uploads"
-
-	err := r.ParseMultipartForm(maxUploadSize)
+func NewItemHandler(cfg *config.Config, itemService *service.ItemService, variants *service.VariantService, uploadService *service.UploadService, blobs storage.Blob) (*ItemHandler, error) {
+	uploads, err := filetype.ParseAllowList(cfg.Items.UploadTypes)
 	if err != nil {
-		h.JSON(w, http.StatusBadRequest, "file too large")
-		return
+		return nil, err
 	}
 
-	file, fileHeader, err := r.FormFile("file")
-	if err != nil {
-		h.JSON(w, http.StatusBadRequest, "file is required")
-		return
+	secret := []byte(cfg.Links.Secret)
+	if len(secret) == 0 {
+		slog.Warn("SHARE_LINK_SECRET is not set, signed image URLs will not survive a restart")
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
 	}
-	defer func(file multipart.File) {
-		err := file.Close()
-		if err != nil {
-			h.JSON(w, http.StatusInternalServerError, "failed to close file")
-			return
-		}
-	}(file)
 
-	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	if !isAllowedExtension(ext) {
-		h.JSON(w, http.StatusForbidden, "file type not allowed")
-		return
-	}
+	return &ItemHandler{
+		Handler:        NewHandler(cfg.ENV),
+		ItemService:    itemService,
+		variants:       variants,
+		blobs:          blobs,
+		requireIfMatch: cfg.Items.RequireIfMatch,
+		signer:         signature.NewSigner(secret),
+		linkImageTTL:   time.Duration(cfg.Links.ImageURLTTLMinutes) * time.Minute,
+		imageURLTTL:    time.Duration(cfg.Items.ImageURLTTLMinutes) * time.Minute,
+		fileMaxAge:     time.Duration(cfg.Items.FileCacheMaxAge) * time.Second,
+		publicMaxAge:   time.Duration(cfg.Items.PublicFileCacheMaxAge) * time.Second,
+		imageLimits:    imaging.Limits{MaxDimension: cfg.Images.MaxDimension, MaxPixels: cfg.Images.MaxPixels},
+		imageMetadata:  cfg.Images.ExtractMetadata,
+		uploads:        uploads,
+		uploadService:  uploadService,
+	}, nil
+}
 
-	//Validate MIME type (sniffing)
-	buffer := make([]byte, 512)
-	if _, err := file.Read(buffer); err != nil && err != io.EOF {
-		h.JSON(w, http.StatusInternalServerError, "failed to read file")
+// Create stores a new item from a multipart form. The image is optional: a
+// "file" part, or the ID of a completed resumable upload as "upload_id".
+// Going over the caller's quota answers 413 for bytes and 422 for items.
+func (h *ItemHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if !h.parseUploadForm(w, r) {
 		return
 	}
 
-	mimeType := http.DetectContentType(buffer)
-	if mimeType != "image/jpeg" && mimeType != "image/png" {
-		h.JSON(w, http.StatusForbidden, "invalid file content")
+	user, ok := h.currentUser(w, r)
+	if !ok {
 		return
 	}
-
-	// reset reader
-	if _, err := file.Seek(0, 0); err != nil {
-		h.JSON(w, http.StatusInternalServerError, "failed to reset file")
+	//Read form fields
+	item := model.Item{
+		UserID:      user.ID,
+		Title:       r.FormValue("title"),
+		Description: r.FormValue("description"),
+		Visibility:  r.FormValue("visibility"),
+	}
+	if item.Visibility == "" {
+		item.Visibility = model.VisibilityPrivate
+	}
+	metadata, err := parseMetadata(r.FormValue("metadata"))
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	item.Metadata = metadata
 
-	// Ensure uploads directory exists
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		h.JSON(w, http.StatusInternalServerError, "failed to create upload dir")
+	if err := validate.Struct(item); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Generate secure filename
-	uniqueName := generateSecureFilename(ext)
-	dstPath := filepath.Join(uploadDir, uniqueName)
-
-	dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	// the image is optional
+	upload, err := h.formUpload(r, user.ID, true)
 	if err != nil {
-		h.JSON(w, http.StatusInternalServerError, "failed to save file")
+		h.uploadError(w, err)
 		return
 	}
-	defer func(dst *os.File) {
-		err := dst.Close()
-		if err != nil {
-			h.JSON(w, http.StatusInternalServerError, "failed to save file")
-			return
+	if upload != nil {
+		item.FilePath = upload.Name // store ONLY filename in DB
+		item.FileChecksum = upload.Checksum
+		item.FileSize = upload.Size
+		if h.imageMetadata {
+			item.Metadata = withImageMetadata(item.Metadata, upload)
 		}
-	}(dst)
+	}
 
-	// Copy with size enforcement
-	if _, err := io.Copy(dst, io.LimitReader(file, maxUploadSize)); err != nil {
-		h.JSON(w, http.StatusInternalServerError, "upload failed")
+	if err := h.ItemService.Save(r.Context(), &item); err != nil {
+		h.discardUpload(r.Context(), upload)
+		if errors.Is(err, service.ErrMetadataInvalid) ||
+			errors.Is(err, repository.ErrStorageQuota) ||
+			errors.Is(err, repository.ErrItemQuota) {
+			h.itemError(w, err)
+			return
+		}
+		h.JSON(w, http.StatusInternalServerError, "failed to create item")
 		return
 	}
+	h.finishUpload(r.Context(), upload)
+	h.variants.Enqueue(item.FilePath)
 
-	user := r.Context().Value("user").(*model.User)
-	//Read form fields
-	item := model.Item{
-		UserID:      user.ID,
-		Title:       r.FormValue("title"),
-		Description: r.FormValue("description"),
-		FilePath:    uniqueName, // store ONLY filename in DB
+	w.Header().Set("ETag", item.ETag)
+	h.JSON(w, http.StatusCreated, item)
+}
+
+// GetAll lists the caller's own items and the items shared with them. See
+// parseItemQuery for the filters and orders it supports.
+func (h *ItemHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
 	}
 
-	if err := validate.Struct(item); err != nil {
+	query, err := parseItemQuery(r.URL.Query())
+	if err != nil {
 		h.JSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := h.ItemService.Save(r.Context(), item); err != nil {
-		h.JSON(w, http.StatusInternalServerError, "failed to create item")
+	items, err := h.ItemService.GetAll(r.Context(), user.ID, query)
+	if err != nil {
+		h.itemError(w, err)
 		return
 	}
 
-	h.JSON(w, http.StatusCreated, item)
-}
-func (h *ItemHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	items, err := h.ItemService.GetAll(r.Context())
-	if err != nil {
-		h.JSON(w, http.StatusInternalServerError, err.Error())
+	etag := listETag(items)
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 	h.JSON(w, http.StatusOK, items)
 }
 func (h *ItemHandler) GetOne(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
 
-	item, err := h.ItemService.GetOne(r.Context(), id)
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.ItemService.GetOne(r.Context(), id, user.ID)
 	if err != nil {
-		h.JSON(w, http.StatusNotFound, err.Error())
+		h.itemError(w, err)
 		return
 	}
 
+	item.ETag = itemETag(item)
+	w.Header().Set("ETag", item.ETag)
+	if notModified(r, item.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	h.JSON(w, http.StatusOK, item)
 }
 func (h *ItemHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
 
-	item, err := h.ItemService.GetOne(r.Context(), id)
+	item, err := h.ItemService.GetOne(r.Context(), id, user.ID)
 	if err != nil {
-		h.JSON(w, http.StatusNotFound, err.Error())
+		h.itemError(w, err)
+		return
+	}
+
+	expected, ok := h.expectedVersion(w, r, id, user.ID)
+	if !ok {
 		return
 	}
 
 	// delete db record
-	if err := h.ItemService.Delete(r.Context(), id); err != nil {
-		h.JSON(w, http.StatusInternalServerError, err.Error())
+	if err := h.ItemService.Delete(r.Context(), id, user.ID, expected); err != nil {
+		h.itemError(w, err)
 		return
 	}
 
-	// delete file
-	_ = os.Remove(filepath.Join("uploads", item.FilePath))
+	// delete files the cascade left unreferenced
+	h.releaseUpload(r.Context(), item.FilePath)
+	for _, attachment := range item.Attachments {
+		h.releaseUpload(r.Context(), attachment.FileName)
+	}
 
 	h.JSON(w, http.StatusOK, map[string]string{"message": "item deleted"})
 }
-func (h *ItemHandler) Update(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
 
-	var item model.UpdateItem
-	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-		h.JSON(w, http.StatusBadRequest, err.Error())
+// Update applies a JSON Merge Patch (RFC 7396, also accepted as plain
+// application/json) or a JSON Patch (RFC 6902) to the item. Only fields the patch
+// changes are written, and the patched item must still pass validation.
+func (h *ItemHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
 		return
 	}
 
-	if err := validate.Struct(item); err != nil {
+	const maxPatchSize = 1 << 20 // 1MB
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize))
+	if err != nil {
 		h.JSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	err := h.ItemService.Update(r.Context(), id, item)
-	if err != nil {
-		h.JSON(w, http.StatusNotFound, err.Error())
+	user, ok := h.currentUser(w, r)
+	if !ok {
 		return
 	}
 
-	h.JSON(w, http.StatusNoContent, map[string]string{"message": "item updated"})
-}
-func (h *ItemHandler) ViewImage(w http.ResponseWriter, r *http.Request) {
-	filename := chi.URLParam(r, "filename")
-
-	// basic hardening
-	if strings.Contains(filename, "..") {
-		http.Error(w, "invalid filename", http.StatusBadRequest)
+	expected, ok := h.expectedVersion(w, r, id, user.ID)
+	if !ok {
 		return
 	}
 
-	path := filepath.Join("uploads", filename)
+	current, err := h.ItemService.GetOne(r.Context(), id, user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	// the patch is computed against this read, so the write must find the same version
+	if expected == 0 {
+		expected = current.Version
+	} else if expected != current.Version {
+		h.itemError(w, repository.ErrVersionMismatch)
+		return
+	}
 
-	file, err := os.Open(path)
+	patched, update, err := applyItemPatch(current, r.Header.Get("Content-Type"), body)
 	if err != nil {
-		http.NotFound(w, r)
+		h.patchError(w, err)
 		return
 	}
-	defer file.Close()
 
-	// sniff content-type
-	buffer := make([]byte, 512)
-	n, _ := file.Read(buffer)
-	contentType := http.DetectContentType(buffer[:n])
+	if err := validate.Struct(patched); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(update); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// only images
-	if contentType != "image/jpeg" && contentType != "image/png" {
-		http.Error(w, "unsupported file type", http.StatusForbidden)
+	version, err := h.ItemService.Update(r.Context(), id, user.ID, expected, update)
+	if err != nil {
+		h.itemError(w, err)
 		return
 	}
 
-	file.Seek(0, 0)
-	w.Header().Set("Content-Type", contentType)
-	io.Copy(w, file)
+	w.Header().Set("ETag", model.ItemETag(version))
+
+	h.JSON(w, http.StatusNoContent, map[string]string{"message": "item updated"})
 }
 
-func isAllowedExtension(fileName string) bool {
-	allowedExtensions := []string{".jpg", ".jpeg", ".png"}
-	ext := strings.ToLower(filepath.Ext(fileName))
-	for _, allowed := range allowedExtensions {
-		if ext == strings.ToLower(allowed) {
-			return true
+// parseItemQuery reads the GET /items parameters: meta.* filters,
+// ?favorite=true and ?sort=created_at|position.
+func parseItemQuery(query url.Values) (model.ItemQuery, error) {
+	var q model.ItemQuery
+	var err error
+	if q.Meta, err = parseMetaFilters(query); err != nil {
+		return q, err
+	}
+	if raw := query.Get("favorite"); raw != "" {
+		if q.Favorite, err = strconv.ParseBool(raw); err != nil {
+			return q, errors.New("favorite must be true or false")
 		}
 	}
-	return false
+	switch q.Sort = query.Get("sort"); q.Sort {
+	case "", model.SortCreatedAt, model.SortPosition:
+	default:
+		return q, errors.New("sort must be created_at or position")
+	}
+	return q, nil
 }
 
-func generateSecureFilename(ext string) string {
-	b := make([]byte, 16)
-	_, err := rand.Read(b)
-	if err != nil {
-		return ""
+// itemID returns the item id of the route, answering 404 when it is not a
+// UUID, so a malformed id never reaches the database.
+func (h *ItemHandler) itemID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		h.JSON(w, http.StatusNotFound, repository.ErrItemNotFound.Error())
+		return "", false
+	}
+	return id, true
+}
+
+// itemError maps service and repository errors onto HTTP status codes.
+// Unexpected errors are logged and answered with a generic message, since
+// they may carry database details.
+func (h *ItemHandler) itemError(w http.ResponseWriter, err error) {
+	status := itemErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		slog.Error("item request failed", "err", err)
+		h.JSON(w, status, http.StatusText(status))
+		return
+	}
+	h.JSON(w, status, err.Error())
+}
+
+func itemErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrItemNotFound),
+		errors.Is(err, repository.ErrRevisionNotFound),
+		errors.Is(err, repository.ErrAttachmentNotFound),
+		errors.Is(err, repository.ErrShareNotFound),
+		errors.Is(err, repository.ErrShareUser),
+		errors.Is(err, repository.ErrLinkNotFound),
+		errors.Is(err, repository.ErrCommentNotFound),
+		errors.Is(err, repository.ErrMetadataSchemaNotFound),
+		errors.Is(err, repository.ErrTemplateNotFound),
+		errors.Is(err, repository.ErrUserNotFound),
+		errors.Is(err, service.ErrNoImage),
+		errors.Is(err, repository.ErrImageVariantNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrInvalidParentComment),
+		errors.Is(err, service.ErrMetadataInvalid),
+		errors.Is(err, repository.ErrInvalidAnchor),
+		errors.Is(err, repository.ErrItemQuota):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, repository.ErrLinkGone):
+		return http.StatusGone
+	case errors.Is(err, service.ErrLinkExpiry):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrLinkPassword):
+		return http.StatusUnauthorized
+	case errors.Is(err, repository.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, repository.ErrAttachmentLimit),
+		errors.Is(err, repository.ErrShareWithOwner):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrInvalidOrder),
+		errors.Is(err, service.ErrInvalidMetadataSchema),
+		errors.Is(err, service.ErrUnknownVariant):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrBulkTooLarge),
+		errors.Is(err, repository.ErrStorageQuota):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
 	}
-	return hex.EncodeToString(b) + ext
 }
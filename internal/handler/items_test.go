@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mastery-project/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestItemIDMalformed(t *testing.T) {
+	// the handler has no service, so reaching it past the id check would panic
+	h := &ItemHandler{}
+	r := chi.NewRouter()
+	r.Get("/items/{id}", h.GetOne)
+	r.Get("/items/{id}/revisions", h.ListRevisions)
+	r.Get("/public/items/{id}", h.PublicItem)
+
+	for _, path := range []string{"/items/42", "/items/not-a-uuid/revisions", "/public/items/'%20OR%201=1"} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("got %d, want 404", w.Code)
+			}
+		})
+	}
+}
+
+func TestItemError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		body   string
+	}{
+		{fmt.Errorf("get item: %w", repository.ErrItemNotFound), http.StatusNotFound, "get item: " + repository.ErrItemNotFound.Error()},
+		{repository.ErrVersionMismatch, http.StatusPreconditionFailed, repository.ErrVersionMismatch.Error()},
+		{errors.New(`ERROR: invalid input syntax for type uuid: "42"`), http.StatusInternalServerError, "Internal Server Error"},
+	}
+	h := &ItemHandler{}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.itemError(w, tt.err)
+			var body string
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.status || body != tt.body {
+				t.Fatalf("got %d %q, want %d %q", w.Code, body, tt.status, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"mastery-project/internal/service"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// CreateLink makes a public link to the item. The token is only returned here.
+func (h *ItemHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CreateLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	link, err := h.ItemService.CreateLink(r.Context(), id, user.ID, req)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	link.URL = linkPath(link.Token)
+
+	w.Header().Set("Location", link.URL)
+	h.JSON(w, http.StatusCreated, link)
+}
+
+func (h *ItemHandler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.ItemService.Links(r.Context(), id, user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, links)
+}
+
+func (h *ItemHandler) RevokeLink(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+	linkID := chi.URLParam(r, "linkID")
+
+	if _, err := uuid.Parse(linkID); err != nil {
+		h.JSON(w, http.StatusNotFound, repository.ErrLinkNotFound.Error())
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	link, err := h.ItemService.RevokeLink(r.Context(), id, user.ID, linkID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, link)
+}
+
+// ViewLink renders the item behind a public link. Password protected links
+// first get a form that posts the password back to the same URL. Every
+// rendered page counts as one view.
+func (h *ItemHandler) ViewLink(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	// the token is in the URL, keep it out of caches and Referer headers
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	link, err := h.ItemService.ResolveLink(r.Context(), token)
+	if err != nil {
+		h.linkError(w, err)
+		return
+	}
+
+	var password string
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, 4<<10)
+		if err := r.ParseForm(); err != nil {
+			renderLinkPage(w, http.StatusBadRequest, linkPageData{Message: "Invalid form."})
+			return
+		}
+		password = r.PostFormValue("password")
+	}
+	if link.HasPassword && r.Method != http.MethodPost {
+		renderLinkPage(w, http.StatusOK, linkPageData{NeedsPassword: true})
+		return
+	}
+
+	item, err := h.ItemService.ViewLink(r.Context(), link, password)
+	if err != nil {
+		h.linkError(w, err)
+		return
+	}
+
+	data := linkPageData{Item: item}
+	if item.FilePath != "" {
+		data.ImageURL = linkPath(token) + "/image?" + h.signer.Query(linkImagePayload(token), h.linkImageTTL).Encode()
+	}
+	renderLinkPage(w, http.StatusOK, data)
+}
+
+// LinkImage serves the image of a linked item. The token alone is not enough:
+// the URL must carry the signature from a rendered page, so a password
+// protected link does not leak its image.
+func (h *ItemHandler) LinkImage(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	if !h.signer.Verify(linkImagePayload(token), r.URL.Query()) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+		return
+	}
+
+	item, err := h.ItemService.LinkImage(r.Context(), token)
+	if err != nil {
+		http.Error(w, err.Error(), itemErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", cacheControl("private", signedMaxAge(r.URL.Query())))
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	h.serveImage(w, r, item)
+}
+
+func (h *ItemHandler) linkError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrLinkPassword):
+		renderLinkPage(w, http.StatusUnauthorized, linkPageData{NeedsPassword: true, Message: "Incorrect password."})
+	case errors.Is(err, repository.ErrLinkNotFound), errors.Is(err, repository.ErrItemNotFound):
+		renderLinkPage(w, http.StatusNotFound, linkPageData{Message: "This link does not exist."})
+	case errors.Is(err, repository.ErrLinkGone):
+		renderLinkPage(w, http.StatusGone, linkPageData{Message: "This link has expired."})
+	default:
+		slog.Error("link", "err", err)
+		renderLinkPage(w, http.StatusInternalServerError, linkPageData{Message: "Something went wrong."})
+	}
+}
+
+func linkPath(token string) string {
+	return "/s/" + url.PathEscape(token)
+}
+
+func linkImagePayload(token string) string {
+	return "link-image:" + token
+}
+
+type linkPageData struct {
+	Item          *model.Item
+	ImageURL      string
+	NeedsPassword bool
+	Message       string
+}
+
+func renderLinkPage(w http.ResponseWriter, status int, data linkPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := linkPage.Execute(w, data); err != nil {
+		slog.Error("link page", "err", err)
+	}
+}
+
+var linkPage = template.Must(template.New("link").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{if .Item}}{{.Item.Title}}{{else}}Shared item{{end}}</title>
+<style>
+body { font-family: sans-serif; max-width: 40rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+img { max-width: 100%; height: auto; }
+.message { color: #a00; }
+</style>
+</head>
+<body>
+{{if .Message}}<p class="message">{{.Message}}</p>{{end}}
+{{if .NeedsPassword}}
+<form method="post">
+<label for="password">This item is password protected.</label>
+<input id="password" name="password" type="password" required autofocus>
+<button type="submit">View</button>
+</form>
+{{end}}
+{{with .Item}}
+<h1>{{.Title}}</h1>
+{{if $.ImageURL}}<p><img src="{{$.ImageURL}}" alt="{{.Title}}"></p>{{end}}
+<p>{{.Description}}</p>
+{{end}}
+</body>
+</html>
+`))
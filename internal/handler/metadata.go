@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mastery-project/internal/model"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxMetadataSize caps an item's metadata, measured as encoded JSON.
+	maxMetadataSize = 16 << 10 // 16KB
+	// maxMetaFilters caps the meta.* parameters accepted by GET /items.
+	maxMetaFilters = 10
+	// maxMetaDepth caps how deep a meta.* filter may reach into the metadata.
+	maxMetaDepth = 5
+)
+
+var (
+	metaKeyPattern    = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	metaFilterPattern = regexp.MustCompile(`^meta\.([^\[\]]+)(?:\[(gt|gte|lt|lte)\])?$`)
+)
+
+// parseMetadata decodes the metadata form field; an empty field means none.
+func parseMetadata(raw string) (model.Metadata, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var metadata model.Metadata
+	if err := json.Unmarshal([]byte(raw), &metadata); err != nil || metadata == nil {
+		return nil, errors.New("metadata must be a JSON object")
+	}
+	if err := checkMetadataSize(metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// withImageMetadata adds the dimensions and capture time of an uploaded image
+// as image_width, image_height and captured_at, keeping any value the client
+// set for those keys.
+func withImageMetadata(metadata model.Metadata, upload *storedUpload) model.Metadata {
+	if metadata == nil {
+		metadata = model.Metadata{}
+	}
+	extracted := model.Metadata{
+		"image_width":  upload.Width,
+		"image_height": upload.Height,
+	}
+	if upload.CapturedAt != nil {
+		extracted["captured_at"] = upload.CapturedAt.UTC().Format(time.RFC3339)
+	}
+	for key, value := range extracted {
+		if _, ok := metadata[key]; !ok {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+func checkMetadataSize(metadata model.Metadata) error {
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if len(encoded) > maxMetadataSize {
+		return fmt.Errorf("metadata must not exceed %d bytes", maxMetadataSize)
+	}
+	return nil
+}
+
+// parseMetaFilters reads the meta.* filters of GET /items. meta.a.b=v matches
+// items whose metadata has v at a.b; meta.a[gte]=n (also gt, lt, lte) compares
+// numerically. Repeated filters must all match.
+func parseMetaFilters(query url.Values) ([]model.MetaFilter, error) {
+	var filters []model.MetaFilter
+	for key, values := range query {
+		if !strings.HasPrefix(key, "meta.") {
+			continue
+		}
+		match := metaFilterPattern.FindStringSubmatch(key)
+		if match == nil {
+			return nil, fmt.Errorf("invalid metadata filter %q", key)
+		}
+		path := strings.Split(match[1], ".")
+		if len(path) > maxMetaDepth {
+			return nil, fmt.Errorf("metadata filter %q is nested too deeply", key)
+		}
+		for _, segment := range path {
+			if !metaKeyPattern.MatchString(segment) {
+				return nil, fmt.Errorf("invalid metadata key %q", segment)
+			}
+		}
+
+		op := "eq"
+		if match[2] != "" {
+			op = match[2]
+		}
+		for _, value := range values {
+			if op != "eq" {
+				n, err := strconv.ParseFloat(value, 64)
+				if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+					return nil, fmt.Errorf("metadata filter %q needs a number", key)
+				}
+				value = strconv.FormatFloat(n, 'f', -1, 64)
+			}
+			filters = append(filters, model.MetaFilter{Path: path, Op: op, Value: value})
+		}
+	}
+	if len(filters) > maxMetaFilters {
+		return nil, fmt.Errorf("at most %d metadata filters are allowed", maxMetaFilters)
+	}
+	return filters, nil
+}
+
+func (h *ItemHandler) GetMetadataSchema(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	schema, err := h.ItemService.MetadataSchema(r.Context(), user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, schema)
+}
+
+// PutMetadataSchema sets the JSON Schema the caller's item metadata must satisfy.
+func (h *ItemHandler) PutMetadataSchema(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	const maxSchemaSize = 64 << 10 // 64KB
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSchemaSize+1))
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(body) > maxSchemaSize {
+		h.JSON(w, http.StatusRequestEntityTooLarge, "schema too large")
+		return
+	}
+	if !json.Valid(body) {
+		h.JSON(w, http.StatusBadRequest, "schema must be valid JSON")
+		return
+	}
+
+	if err := h.ItemService.SetMetadataSchema(r.Context(), user.ID, body); err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, json.RawMessage(body))
+}
+
+func (h *ItemHandler) DeleteMetadataSchema(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ItemService.DeleteMetadataSchema(r.Context(), user.ID); err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "metadata schema deleted"})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+	"mastery-project/internal/patch"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+// patchableItemFields are the members of the document PATCH /items/{id} operates on.
+var patchableItemFields = map[string]bool{"title": true, "description": true, "visibility": true, "metadata": true}
+
+// applyItemPatch applies a merge patch or JSON patch body to the item and returns
+// the resulting item together with an UpdateItem that only carries changed fields.
+func applyItemPatch(item *model.Item, contentType string, body []byte) (*model.Item, model.UpdateItem, error) {
+	doc := map[string]any{
+		"title":       item.Title,
+		"description": item.Description,
+		"visibility":  item.Visibility,
+		"metadata":    map[string]any(item.Metadata),
+	}
+
+	mediaType := patch.MergePatchType
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, model.UpdateItem{}, errUnsupportedPatch
+		}
+		mediaType = parsed
+	}
+
+	var result any
+	var err error
+	switch mediaType {
+	case patch.MergePatchType, "application/json":
+		result, err = patch.MergePatch(doc, body)
+	case patch.JSONPatchType:
+		result, err = patch.JSONPatch(doc, body)
+	default:
+		return nil, model.UpdateItem{}, errUnsupportedPatch
+	}
+	if err != nil {
+		return nil, model.UpdateItem{}, err
+	}
+
+	patched, ok := result.(map[string]any)
+	if !ok {
+		return nil, model.UpdateItem{}, fmt.Errorf("%w: item must remain an object", patch.ErrCannotApply)
+	}
+	for field := range patched {
+		if !patchableItemFields[field] {
+			return nil, model.UpdateItem{}, fmt.Errorf("%w: field %q cannot be patched", patch.ErrCannotApply, field)
+		}
+	}
+
+	updated := *item
+	var update model.UpdateItem
+	if updated.Title, ok = stringField(patched, "title"); !ok {
+		return nil, model.UpdateItem{}, fmt.Errorf("%w: title must be a string", patch.ErrCannotApply)
+	}
+	if updated.Description, ok = stringField(patched, "description"); !ok {
+		return nil, model.UpdateItem{}, fmt.Errorf("%w: description must be a string", patch.ErrCannotApply)
+	}
+	if updated.Visibility, ok = stringField(patched, "visibility"); !ok {
+		return nil, model.UpdateItem{}, fmt.Errorf("%w: visibility must be a string", patch.ErrCannotApply)
+	}
+	if updated.Metadata, ok = metadataField(patched); !ok {
+		return nil, model.UpdateItem{}, fmt.Errorf("%w: metadata must be an object", patch.ErrCannotApply)
+	}
+	if err := checkMetadataSize(updated.Metadata); err != nil {
+		return nil, model.UpdateItem{}, fmt.Errorf("%w: %v", patch.ErrCannotApply, err)
+	}
+	if updated.Title != item.Title {
+		update.Title = &updated.Title
+	}
+	if updated.Description != item.Description {
+		update.Description = &updated.Description
+	}
+	if updated.Visibility != item.Visibility {
+		update.Visibility = &updated.Visibility
+	}
+	if !reflect.DeepEqual(updated.Metadata, item.Metadata) {
+		update.Metadata = updated.Metadata
+	}
+	return &updated, update, nil
+}
+
+var errUnsupportedPatch = errors.New("content type must be " + patch.MergePatchType + " or " + patch.JSONPatchType)
+
+// stringField reads a string member; a missing member yields "" so validation
+// reports it as a required field.
+func stringField(doc map[string]any, key string) (string, bool) {
+	value, exists := doc[key]
+	if !exists {
+		return "", true
+	}
+	str, ok := value.(string)
+	return str, ok
+}
+
+// metadataField reads the metadata member; a missing or null member clears it.
+func metadataField(doc map[string]any) (model.Metadata, bool) {
+	value, exists := doc["metadata"]
+	if !exists || value == nil {
+		return model.Metadata{}, true
+	}
+	obj, ok := value.(map[string]any)
+	return obj, ok
+}
+
+func (h *ItemHandler) patchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errUnsupportedPatch):
+		h.JSON(w, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, patch.ErrInvalidPatch):
+		h.JSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, patch.ErrTestFailed):
+		h.JSON(w, http.StatusConflict, err.Error())
+	default:
+		h.JSON(w, http.StatusUnprocessableEntity, err.Error())
+	}
+}
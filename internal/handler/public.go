@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// PublicItems is the unauthenticated feed of public items, paged with
+// ?limit= and ?offset=.
+func (h *ItemHandler) PublicItems(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, err := h.ItemService.PublicItems(r.Context(), limit, offset)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+
+	etag := listETag(items)
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.JSON(w, http.StatusOK, items)
+}
+
+// PublicItem shows an unlisted or public item without authentication.
+func (h *ItemHandler) PublicItem(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.ItemService.PublicItem(r.Context(), id)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+
+	item.ETag = itemETag(item)
+	w.Header().Set("ETag", item.ETag)
+	if notModified(r, item.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.JSON(w, http.StatusOK, item)
+}
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageParams reads the ?limit= and ?offset= query parameters.
+func pageParams(r *http.Request) (int, int, error) {
+	limit := defaultPageLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxPageLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		limit = parsed
+	}
+	offset := 0
+	if raw := r.URL.Query().Get("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return 0, 0, errors.New("offset must be a non-negative number")
+		}
+		offset = parsed
+	}
+	return limit, offset, nil
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"mastery-project/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *ItemHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.ItemService.Revisions(r.Context(), id, user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, revisions)
+}
+
+func (h *ItemHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	rev, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	revision, err := h.ItemService.Revision(r.Context(), id, user.ID, rev)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, revision)
+}
+
+// DiffRevisions compares ?from= against ?to=; to defaults to the latest revision.
+func (h *ItemHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, "from must be a revision number")
+		return
+	}
+
+	var to int
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		to, err = strconv.Atoi(raw)
+		if err != nil {
+			h.JSON(w, http.StatusBadRequest, "to must be a revision number")
+			return
+		}
+	} else {
+		revisions, err := h.ItemService.Revisions(r.Context(), id, user.ID)
+		if err != nil {
+			h.itemError(w, err)
+			return
+		}
+		if len(revisions) == 0 {
+			h.JSON(w, http.StatusNotFound, repository.ErrRevisionNotFound.Error())
+			return
+		}
+		to = revisions[0].Revision
+	}
+
+	diff, err := h.ItemService.DiffRevisions(r.Context(), id, user.ID, from, to)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, diff)
+}
+
+func (h *ItemHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	rev, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	expected, ok := h.expectedVersion(w, r, id, user.ID)
+	if !ok {
+		return
+	}
+
+	item, err := h.ItemService.RestoreRevision(r.Context(), id, rev, user.ID, expected)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	w.Header().Set("ETag", item.ETag)
+	h.JSON(w, http.StatusOK, item)
+}
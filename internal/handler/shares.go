@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"mastery-project/internal/model"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// SharedWithMe lists the items other users have shared with the caller.
+func (h *ItemHandler) SharedWithMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := h.ItemService.SharedWithMe(r.Context(), user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, items)
+}
+
+func (h *ItemHandler) ListShares(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	shares, err := h.ItemService.Shares(r.Context(), id, user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, shares)
+}
+
+// Share grants the user with the given email viewer or editor access. Sharing
+// again with the same user changes their permission.
+func (h *ItemHandler) Share(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.ShareItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	share, err := h.ItemService.Share(r.Context(), id, user.ID, req)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, share)
+}
+
+// Unshare revokes a user's access; users may also remove their own share.
+func (h *ItemHandler) Unshare(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	granteeID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		h.JSON(w, http.StatusNotFound, "share not found")
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ItemService.Unshare(r.Context(), id, user.ID, granteeID); err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "share removed"})
+}
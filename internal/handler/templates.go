@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Duplicate copies the item into a new item owned by the caller. The optional
+// body overrides fields of the copy.
+func (h *ItemHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	overrides, ok := h.itemOverrides(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.ItemService.Duplicate(r.Context(), id, user.ID, overrides)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	w.Header().Set("ETag", item.ETag)
+	h.JSON(w, http.StatusCreated, item)
+}
+
+// SaveTemplate saves the item's fields as a template named by the body.
+func (h *ItemHandler) SaveTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.itemID(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.SaveTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	template, err := h.ItemService.SaveTemplate(r.Context(), id, user.ID, req.Name)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusCreated, template)
+}
+
+func (h *ItemHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	templates, err := h.ItemService.Templates(r.Context(), user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, templates)
+}
+
+func (h *ItemHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, ok := h.templateID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	template, err := h.ItemService.Template(r.Context(), templateID, user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, template)
+}
+
+func (h *ItemHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, ok := h.templateID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ItemService.DeleteTemplate(r.Context(), templateID, user.ID); err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, map[string]string{"message": "template deleted"})
+}
+
+// CreateFromTemplate creates an item from the template. The optional body
+// overrides fields of the new item.
+func (h *ItemHandler) CreateFromTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, ok := h.templateID(w, r)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	overrides, ok := h.itemOverrides(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.ItemService.CreateFromTemplate(r.Context(), templateID, user.ID, overrides)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	w.Header().Set("ETag", item.ETag)
+	h.JSON(w, http.StatusCreated, item)
+}
+
+// itemOverrides decodes an optional ItemOverrides body; an empty body means no
+// overrides.
+func (h *ItemHandler) itemOverrides(w http.ResponseWriter, r *http.Request) (model.ItemOverrides, bool) {
+	var overrides model.ItemOverrides
+	if err := json.NewDecoder(r.Body).Decode(&overrides); err != nil && !errors.Is(err, io.EOF) {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return overrides, false
+	}
+	if err := validate.Struct(overrides); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return overrides, false
+	}
+	if err := checkMetadataSize(overrides.Metadata); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return overrides, false
+	}
+	return overrides, true
+}
+
+func (h *ItemHandler) templateID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	templateID := chi.URLParam(r, "templateID")
+	if _, err := uuid.Parse(templateID); err != nil {
+		h.JSON(w, http.StatusNotFound, repository.ErrTemplateNotFound.Error())
+		return "", false
+	}
+	return templateID, true
+}
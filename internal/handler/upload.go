@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mastery-project/internal/filetype"
+	"mastery-project/internal/imaging"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"mastery-project/internal/service"
+	"mastery-project/internal/storage"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// uploadMemory is how much of a multipart form is held in memory; larger
+	// files spill to temporary files.
+	uploadMemory = 5 << 20 // 5MB
+	// formOverhead allows for the form fields and multipart framing on top of
+	// the largest file an upload form may carry.
+	formOverhead = 1 << 20 // 1MB
+)
+
+var (
+	errFileTooLarge       = errors.New("file too large")
+	errFileTypeNotAllowed = errors.New("file type not allowed")
+	errInvalidFileContent = errors.New("invalid file content")
+	errImageTooLarge      = errors.New("image dimensions are too large")
+	errInvalidUploadForm  = errors.New("invalid upload")
+)
+
+// storedUpload describes a file saveUpload has written to blob storage.
+type storedUpload struct {
+	Name         string
+	OriginalName string
+	Size         int64
+	MimeType     string
+	Checksum     string
+	Width        int
+	Height       int
+	CapturedAt   *time.Time
+	// resumable is the resumable upload the file was read from, if any.
+	resumable *model.Upload
+}
+
+func (h *ItemHandler) maxUploadBody() int64 {
+	return h.uploads.MaxSize() + formOverhead
+}
+
+// parseUploadForm parses a multipart upload, refusing bodies larger than the
+// largest allowed file type permits.
+func (h *ItemHandler) parseUploadForm(w http.ResponseWriter, r *http.Request) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadBody())
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		h.JSON(w, http.StatusBadRequest, "file too large")
+		return false
+	}
+	return true
+}
+
+// saveUpload checks an uploaded file against the allow-list: its extension, its
+// type's size limit and that the sniffed MIME type matches the extension.
+// Images, the only types accepted when imageOnly is set, are stored without
+// their EXIF, XMP, ICC and text metadata; other types are stored as sent. The
+// file goes into blob storage under its SHA-256, so a file uploaded twice is
+// stored once, and stays pinned until finishUpload or discardUpload.
+func (h *ItemHandler) saveUpload(ctx context.Context, file io.Reader, fileName string, size int64, imageOnly bool) (*storedUpload, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	rule, err := h.uploads.ForExtension(ext)
+	if err != nil || (imageOnly && !rule.Image) {
+		return nil, errFileTypeNotAllowed
+	}
+	if size > rule.MaxSize {
+		return nil, fmt.Errorf("%w: %s files are limited to %d bytes", errFileTooLarge, rule.Name, rule.MaxSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, rule.MaxSize+1))
+	if err != nil {
+		return nil, errors.New("failed to read file")
+	}
+	if int64(len(data)) > rule.MaxSize {
+		return nil, fmt.Errorf("%w: %s files are limited to %d bytes", errFileTooLarge, rule.Name, rule.MaxSize)
+	}
+
+	//Validate MIME type (sniffing) against the extension
+	mimeType := http.DetectContentType(data[:min(len(data), 512)])
+	if mimeType != rule.MimeType {
+		return nil, errInvalidFileContent
+	}
+
+	upload := &storedUpload{
+		OriginalName: filepath.Base(fileName),
+		MimeType:     mimeType,
+	}
+	if rule.Image {
+		if data, err = h.normalizeImage(data, upload); err != nil {
+			return nil, err
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	upload.Checksum = hex.EncodeToString(sum[:])
+	upload.Size = int64(len(data))
+	upload.Name = storage.ContentKey(upload.Checksum, rule.Extensions[0])
+	if err := h.storeBlob(ctx, upload, data); err != nil {
+		slog.Error("storing upload failed", "file", upload.Name, "err", err)
+		return nil, errors.New("upload failed")
+	}
+	return upload, nil
+}
+
+// storeBlob pins the upload's file and writes it, unless an identical file is
+// stored already.
+func (h *ItemHandler) storeBlob(ctx context.Context, upload *storedUpload, data []byte) error {
+	if err := h.ItemService.PinFile(ctx, upload.Name, upload.Checksum, upload.Size); err != nil {
+		return err
+	}
+	if info, err := h.blobs.Stat(ctx, upload.Name); err == nil && info.Size == upload.Size {
+		return nil
+	}
+	if err := h.blobs.Put(ctx, upload.Name, bytes.NewReader(data), upload.Size, upload.MimeType); err != nil {
+		h.discardUpload(ctx, upload)
+		return err
+	}
+	return nil
+}
+
+// formUpload stores the file of an upload form: either its "file" part or the
+// completed resumable upload named by "upload_id". It returns nil when the form
+// has neither. A resumable upload is kept until finishUpload, so the client can
+// retry with it when the row referencing the file cannot be saved.
+func (h *ItemHandler) formUpload(r *http.Request, userID uuid.UUID, imageOnly bool) (*storedUpload, error) {
+	uploadID := r.FormValue("upload_id")
+	file, fileHeader, err := r.FormFile("file")
+	switch {
+	case err == nil && uploadID != "":
+		file.Close()
+		return nil, fmt.Errorf("%w: send either a file or an upload_id", errInvalidUploadForm)
+	case uploadID != "":
+		return h.saveResumable(r.Context(), uploadID, userID, imageOnly)
+	case errors.Is(err, http.ErrMissingFile):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("%w: invalid file", errInvalidUploadForm)
+	}
+	defer file.Close()
+	return h.saveUpload(r.Context(), file, fileHeader.Filename, fileHeader.Size, imageOnly)
+}
+
+// saveResumable stores the data of a completed resumable upload like a form file.
+func (h *ItemHandler) saveResumable(ctx context.Context, uploadID string, userID uuid.UUID, imageOnly bool) (*storedUpload, error) {
+	id, err := uuid.Parse(uploadID)
+	if err != nil {
+		return nil, repository.ErrUploadNotFound
+	}
+	resumable, reader, err := h.uploadService.Open(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	upload, err := h.saveUpload(ctx, reader, resumable.FileName, resumable.Length, imageOnly)
+	if err != nil {
+		return nil, err
+	}
+	upload.resumable = resumable
+	return upload, nil
+}
+
+// finishUpload unpins a stored file once a row references it, and discards
+// the resumable upload it came from.
+func (h *ItemHandler) finishUpload(ctx context.Context, upload *storedUpload) {
+	if upload == nil {
+		return
+	}
+	if err := h.ItemService.UnpinFile(ctx, upload.Name); err != nil {
+		slog.Warn("could not unpin upload", "file", upload.Name, "err", err)
+	}
+	if upload.resumable == nil {
+		return
+	}
+	if err := h.uploadService.Delete(ctx, upload.resumable.ID, upload.resumable.UserID); err != nil {
+		slog.Warn("could not delete resumable upload", "upload", upload.resumable.ID, "err", err)
+	}
+}
+
+// discardUpload unpins a stored file whose row could not be saved, deleting it
+// unless something else uses the same content.
+func (h *ItemHandler) discardUpload(ctx context.Context, upload *storedUpload) {
+	if upload == nil {
+		return
+	}
+	if err := h.ItemService.UnpinFile(ctx, upload.Name); err != nil {
+		slog.Warn("could not unpin upload", "file", upload.Name, "err", err)
+		return
+	}
+	h.releaseUpload(ctx, upload.Name)
+}
+
+// normalizeImage strips the image's metadata and records its dimensions and
+// capture time in upload.
+func (h *ItemHandler) normalizeImage(data []byte, upload *storedUpload) ([]byte, error) {
+	// Decoding and re-encoding also proves the file is a well-formed image
+	normalized, err := h.imageLimits.Normalize(data)
+	if err != nil {
+		if errors.Is(err, imaging.ErrTooLarge) {
+			return nil, errImageTooLarge
+		}
+		if errors.Is(err, imaging.ErrUnsupported) {
+			return nil, errInvalidFileContent
+		}
+		slog.Error("normalising upload failed", "err", err)
+		return nil, errors.New("upload failed")
+	}
+
+	upload.Width = normalized.Width
+	upload.Height = normalized.Height
+	upload.CapturedAt = normalized.CapturedAt
+	return normalized.Data, nil
+}
+
+// releaseUpload deletes a file that a row has stopped pointing at, with its
+// image variants, once no item image or attachment references it any more;
+// items without an image have no file.
+func (h *ItemHandler) releaseUpload(ctx context.Context, name string) {
+	if name == "" {
+		return
+	}
+	removed, err := h.ItemService.ReleaseFile(ctx, name, func(ctx context.Context) error {
+		return h.blobs.Delete(ctx, name)
+	})
+	if err != nil {
+		slog.Warn("keeping upload, could not release it", "file", name, "err", err)
+		return
+	}
+	if removed {
+		h.variants.Remove(ctx, name)
+	}
+}
+
+func (h Handler) uploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errFileTooLarge), errors.Is(err, errInvalidUploadForm):
+		h.JSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrUploadTooLarge):
+		h.JSON(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, errFileTypeNotAllowed), errors.Is(err, filetype.ErrNotAllowed),
+		errors.Is(err, errInvalidFileContent):
+		h.JSON(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, repository.ErrUploadNotFound):
+		h.JSON(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repository.ErrUploadOffset), errors.Is(err, service.ErrUploadIncomplete):
+		h.JSON(w, http.StatusConflict, err.Error())
+	case errors.Is(err, errImageTooLarge):
+		h.JSON(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		h.JSON(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// serveUpload writes a stored file with its SHA-256 checksum as a strong ETag,
+// answering 404 when it is missing. http.ServeContent then handles Range,
+// If-Range, If-None-Match and If-Modified-Since, whatever the storage driver.
+// A full read is checked against the checksum and cut short when the stored
+// content is corrupt. Files stored before checksums were recorded are hashed
+// on the fly.
+func (h *ItemHandler) serveUpload(w http.ResponseWriter, r *http.Request, name, checksum string) {
+	if name == "" || name != path.Base(name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	file, info, err := h.blobs.Get(r.Context(), name)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrInvalidKey) {
+			slog.Error("reading upload failed", "file", name, "err", err)
+		}
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	if checksum == "" {
+		checksum, err = hashUpload(file)
+		if err != nil {
+			slog.Error("hashing upload failed", "file", name, "err", err)
+			http.Error(w, "failed to read file", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("ETag", `"`+checksum+`"`)
+	if info.ContentType != "" {
+		w.Header().Set("Content-Type", info.ContentType)
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	content := storage.VerifyingReader(file, info.Size, checksum, func() {
+		slog.Error("stored file is corrupt", "file", name, "checksum", checksum)
+	})
+	http.ServeContent(w, r, name, info.ModTime, content)
+}
+
+// hashUpload returns the hex SHA-256 of file and rewinds it.
+func hashUpload(file io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// cacheControl builds a Cache-Control value for scope ("private" or "public");
+// a zero maxAge makes clients revalidate with the ETag on every use.
+func cacheControl(scope string, maxAge time.Duration) string {
+	if maxAge < time.Second {
+		return scope + ", no-cache"
+	}
+	return scope + ", max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// signedMaxAge is how long the verified signed URL in query stays valid, so a
+// cached response never outlives its URL.
+func signedMaxAge(query url.Values) time.Duration {
+	expires, err := strconv.ParseInt(query.Get("exp"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Until(time.Unix(expires, 0))
+}
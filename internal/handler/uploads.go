@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"mastery-project/internal/config"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"mastery-project/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,termination,expiration"
+	tusContentType = "application/offset+octet-stream"
+)
+
+// UploadHandler implements the tus 1.0 resumable upload protocol
+// (https://tus.io/protocols/resumable-upload) with its creation, termination
+// and expiration extensions. A completed upload is used by passing its ID as
+// "upload_id" in place of a "file" part.
+type UploadHandler struct {
+	Handler
+	UploadService *service.UploadService
+}
+
+func NewUploadHandler(cfg *config.Config, uploadService *service.UploadService) *UploadHandler {
+	return &UploadHandler{
+		Handler:       NewHandler(cfg.ENV),
+		UploadService: uploadService,
+	}
+}
+
+// Protocol sets Tus-Resumable on every response and answers 412 to requests
+// for another protocol version. OPTIONS requests need no version.
+func (h *UploadHandler) Protocol(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		if r.Method != http.MethodOptions && r.Header.Get("Tus-Resumable") != tusVersion {
+			w.Header().Set("Tus-Version", tusVersion)
+			h.JSON(w, http.StatusPreconditionFailed, "unsupported tus version")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Options describes the server's tus support.
+func (h *UploadHandler) Options(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(h.UploadService.MaxSize(), 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Create starts an upload of Upload-Length bytes. Upload-Metadata must carry
+// the file's name as "filename" (or "name"), since its extension decides the
+// file type and size limit.
+func (h *UploadHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		h.JSON(w, http.StatusBadRequest, "Upload-Defer-Length is not supported")
+		return
+	}
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		h.JSON(w, http.StatusBadRequest, "Upload-Length must be a positive number of bytes")
+		return
+	}
+	if length > h.UploadService.MaxSize() {
+		h.JSON(w, http.StatusRequestEntityTooLarge, "upload is too large")
+		return
+	}
+
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fileName := metadata["filename"]
+	if fileName == "" {
+		fileName = metadata["name"]
+	}
+	if fileName == "" {
+		h.JSON(w, http.StatusBadRequest, "Upload-Metadata must include a filename")
+		return
+	}
+
+	upload := model.Upload{
+		UserID:   user.ID,
+		FileName: fileName,
+		Length:   length,
+		Metadata: r.Header.Get("Upload-Metadata"),
+	}
+	if err := h.UploadService.Create(r.Context(), &upload); err != nil {
+		h.uploadError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", model.UploadURL(upload.ID))
+	w.Header().Set("Upload-Expires", uploadExpires(upload.ExpiresAt))
+	h.JSON(w, http.StatusCreated, map[string]string{"id": upload.ID.String()})
+}
+
+// Head reports how much of an upload has arrived, so a client can resume it.
+func (h *UploadHandler) Head(w http.ResponseWriter, r *http.Request) {
+	upload, ok := h.upload(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		w.Header().Set("Upload-Metadata", upload.Metadata)
+	}
+	w.Header().Set("Upload-Expires", uploadExpires(upload.ExpiresAt))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Patch appends the body at Upload-Offset, which must be the upload's current
+// offset. When the connection drops midway, the bytes that did arrive are
+// kept, so the client resumes from there.
+func (h *UploadHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	upload, ok := h.upload(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Header.Get("Content-Type") != tusContentType {
+		h.JSON(w, http.StatusUnsupportedMediaType, "Content-Type must be "+tusContentType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		h.JSON(w, http.StatusBadRequest, "Upload-Offset must be a number of bytes")
+		return
+	}
+	if offset != upload.Offset {
+		h.uploadError(w, repository.ErrUploadOffset)
+		return
+	}
+
+	remaining := upload.Length - upload.Offset
+	if r.ContentLength > remaining {
+		h.JSON(w, http.StatusRequestEntityTooLarge, "body is longer than the rest of the upload")
+		return
+	}
+	data, readErr := io.ReadAll(io.LimitReader(r.Body, remaining+1))
+	if int64(len(data)) > remaining {
+		h.JSON(w, http.StatusRequestEntityTooLarge, "body is longer than the rest of the upload")
+		return
+	}
+
+	// the client may be gone already; what it sent is still worth keeping
+	ctx := context.WithoutCancel(r.Context())
+	if err := h.UploadService.Append(ctx, upload, data); err != nil {
+		h.uploadError(w, err)
+		return
+	}
+	if readErr != nil {
+		slog.Info("upload interrupted", "upload", upload.ID, "offset", upload.Offset, "err", readErr)
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Expires", uploadExpires(upload.ExpiresAt))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete terminates an upload and discards its data.
+func (h *UploadHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.uploadError(w, repository.ErrUploadNotFound)
+		return
+	}
+
+	if err := h.UploadService.Delete(r.Context(), id, user.ID); err != nil {
+		h.uploadError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// upload loads the caller's upload named in the URL, answering 404 when there
+// is none.
+func (h *UploadHandler) upload(w http.ResponseWriter, r *http.Request) (*model.Upload, bool) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.uploadError(w, repository.ErrUploadNotFound)
+		return nil, false
+	}
+
+	upload, err := h.UploadService.Get(r.Context(), id, user.ID)
+	if err != nil {
+		h.uploadError(w, err)
+		return nil, false
+	}
+	return upload, true
+}
+
+// parseUploadMetadata decodes an Upload-Metadata header: comma-separated keys,
+// each optionally followed by a space and a base64-encoded value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if key == "" || err != nil {
+			return nil, errors.New("Upload-Metadata must be keys with base64-encoded values")
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// uploadExpires formats an upload's expiry for the Upload-Expires header.
+func uploadExpires(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
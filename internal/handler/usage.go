@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Usage reports the bytes and items the caller's items take up and the quota
+// they count against.
+func (h *ItemHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := h.ItemService.Usage(r.Context(), user.ID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, usage)
+}
+
+// UserUsage is Usage for any user, for admins.
+func (h *ItemHandler) UserUsage(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.itemError(w, repository.ErrUserNotFound)
+		return
+	}
+
+	usage, err := h.ItemService.Usage(r.Context(), userID)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, usage)
+}
+
+// SetUserQuota overrides a user's quota. Each limit left out or null goes back
+// to the configured one; 0 lifts it.
+func (h *ItemHandler) SetUserQuota(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		h.itemError(w, repository.ErrUserNotFound)
+		return
+	}
+
+	var req model.QuotaOverride
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		h.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	usage, err := h.ItemService.SetQuota(r.Context(), userID, req)
+	if err != nil {
+		h.itemError(w, err)
+		return
+	}
+	h.JSON(w, http.StatusOK, usage)
+}
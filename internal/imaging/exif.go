@@ -0,0 +1,151 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+const (
+	tagOrientation        = 0x0112
+	tagExifIFD            = 0x8769
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+
+	typeShort = 3
+	typeLong  = 4
+)
+
+// EXIF holds the few EXIF fields that are applied or kept when an upload is
+// normalised; everything else is dropped with the metadata.
+type EXIF struct {
+	// Orientation is the EXIF orientation, 1 (upright) to 8.
+	Orientation int
+	// CapturedAt is when the photo was taken. Without an offset in the file it
+	// is the camera's local time, reported as UTC.
+	CapturedAt *time.Time
+}
+
+// ReadEXIF reads the EXIF block of a JPEG. Missing or malformed data gives
+// the zero fields rather than an error, since the image is still usable.
+func ReadEXIF(data []byte) EXIF {
+	exif := EXIF{Orientation: 1}
+	tiff := jpegEXIF(data)
+	if len(tiff) < 8 {
+		return exif
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return exif
+	}
+	ifd := tiffIFD{data: tiff, order: order}
+
+	entries := ifd.entries(order.Uint32(tiff[4:]))
+	if value, ok := entries[tagOrientation]; ok && value.typ == typeShort {
+		if o := int(order.Uint16(value.raw)); o >= 1 && o <= 8 {
+			exif.Orientation = o
+		}
+	}
+	if value, ok := entries[tagExifIFD]; ok && value.typ == typeLong {
+		sub := ifd.entries(order.Uint32(value.raw))
+		taken := ifd.ascii(sub[tagDateTimeOriginal])
+		if offset := ifd.ascii(sub[tagOffsetTimeOriginal]); offset != "" {
+			if t, err := time.Parse("2006:01:02 15:04:05-07:00", taken+offset); err == nil {
+				exif.CapturedAt = &t
+				return exif
+			}
+		}
+		if t, err := time.Parse("2006:01:02 15:04:05", taken); err == nil {
+			exif.CapturedAt = &t
+		}
+	}
+	return exif
+}
+
+// jpegEXIF returns the TIFF structure inside a JPEG's APP1 Exif segment.
+func jpegEXIF(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// start of scan or end of image: no metadata follows
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		pos += 2 + length
+	}
+	return nil
+}
+
+type tiffIFD struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type tiffValue struct {
+	typ   uint16
+	count uint32
+	// raw is the 4-byte value field: the value itself when it fits, otherwise
+	// an offset to it.
+	raw []byte
+}
+
+// entries reads the directory at offset; a directory running past the end of
+// the data yields what could be read.
+func (t tiffIFD) entries(offset uint32) map[uint16]tiffValue {
+	entries := make(map[uint16]tiffValue)
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return entries
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	for i := range count {
+		start := int(offset) + 2 + i*12
+		if start+12 > len(t.data) {
+			break
+		}
+		entry := t.data[start : start+12]
+		entries[t.order.Uint16(entry)] = tiffValue{
+			typ:   t.order.Uint16(entry[2:]),
+			count: t.order.Uint32(entry[4:]),
+			raw:   entry[8:12],
+		}
+	}
+	return entries
+}
+
+// ascii returns an ASCII value without its terminating NUL, or "".
+func (t tiffIFD) ascii(value tiffValue) string {
+	if value.count == 0 {
+		return ""
+	}
+	raw := value.raw
+	if value.count > 4 {
+		offset := uint64(t.order.Uint32(value.raw))
+		if offset+uint64(value.count) > uint64(len(t.data)) {
+			return ""
+		}
+		raw = t.data[offset : offset+uint64(value.count)]
+	} else {
+		raw = raw[:value.count]
+	}
+	return strings.TrimRight(string(raw), "\x00 ")
+}
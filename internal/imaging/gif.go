@@ -0,0 +1,64 @@
+package imaging
+
+import "errors"
+
+// GIF block introducers and the flag marking a color table that follows a
+// descriptor.
+const (
+	gifExtension       = 0x21
+	gifImageDescriptor = 0x2c
+	gifTrailer         = 0x3b
+	gifColorTable      = 0x80
+)
+
+var errInvalidGIF = errors.New("invalid gif stream")
+
+// gifFrames counts the frames of a GIF by walking its blocks, without
+// decompressing any of them, so an animation can be refused before its frames
+// are decoded.
+func gifFrames(data []byte) (int, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return 0, errInvalidGIF
+	}
+	pos := 13
+	if data[10]&gifColorTable != 0 {
+		pos += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames := 0
+	for pos < len(data) {
+		switch data[pos] {
+		case gifExtension:
+			pos += 2 // introducer and label
+		case gifImageDescriptor:
+			if pos+10 > len(data) {
+				return 0, errInvalidGIF
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&gifColorTable != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			pos++ // LZW minimum code size
+			frames++
+		case gifTrailer:
+			return frames, nil
+		default:
+			return 0, errInvalidGIF
+		}
+
+		// both extensions and image data end in a run of sub-blocks closed by
+		// an empty one
+		for {
+			if pos >= len(data) {
+				return 0, errInvalidGIF
+			}
+			size := int(data[pos])
+			pos += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+	return 0, errInvalidGIF
+}
@@ -0,0 +1,69 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	"testing"
+)
+
+func encodeGIF(t *testing.T, frames, size int) []byte {
+	t.Helper()
+	anim := &gif.GIF{}
+	for range frames {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, size, size), palette.Plan9))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGIFFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		frames int
+		err    error
+	}{
+		{"one frame", encodeGIF(t, 1, 8), 1, nil},
+		{"animation", encodeGIF(t, 12, 8), 12, nil},
+		{"truncated", encodeGIF(t, 3, 8)[:40], 0, errInvalidGIF},
+		{"not a gif", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x00\x00"), 0, errInvalidGIF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, err := gifFrames(tt.data)
+			if !errors.Is(err, tt.err) || frames != tt.frames {
+				t.Fatalf("got %d, %v; want %d, %v", frames, err, tt.frames, tt.err)
+			}
+		})
+	}
+}
+
+func TestNormalizeGIF(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames int
+		err    error
+	}{
+		{"within the limit", 4, nil},
+		{"frames over the limit", 5, ErrTooLarge},
+	}
+	limits := Limits{MaxDimension: 100, MaxPixels: 4 * 100 * 100}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := limits.Normalize(encodeGIF(t, tt.frames, 100))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if err == nil && (out.Width != 100 || out.Height != 100) {
+				t.Errorf("got %dx%d, want 100x100", out.Width, out.Height)
+			}
+		})
+	}
+}
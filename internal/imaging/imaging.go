@@ -0,0 +1,129 @@
+// Package imaging strips metadata from uploaded images and renders the resized
+// variants served alongside the originals.
+package imaging
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Original names the unresized image in ?size= parameters.
+const Original = "original"
+
+const jpegQuality = 85
+
+var variantName = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Variant is one configured rendition: the image scaled down to fit in a
+// MaxSize x MaxSize box and encoded as Format ("jpeg", "png" or "webp").
+type Variant struct {
+	Name    string
+	MaxSize int
+	Format  string
+}
+
+// ParseVariants reads a comma-separated list of name:max_size:format entries,
+// such as "thumb:200:jpeg,medium:800:jpeg,webp:1600:webp".
+func ParseVariants(spec string) ([]Variant, error) {
+	var variants []Variant
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("image variant %q: want name:max_size:format", entry)
+		}
+		name, format := parts[0], parts[2]
+		if !variantName.MatchString(name) || name == Original || seen[name] {
+			return nil, fmt.Errorf("image variant %q: invalid or duplicate name", entry)
+		}
+		size, err := strconv.Atoi(parts[1])
+		if err != nil || size < 16 || size > 4096 {
+			return nil, fmt.Errorf("image variant %q: max size must be between 16 and 4096", entry)
+		}
+		if ContentType(format) == "" {
+			return nil, fmt.Errorf("image variant %q: format must be jpeg, png or webp", entry)
+		}
+		seen[name] = true
+		variants = append(variants, Variant{Name: name, MaxSize: size, Format: format})
+	}
+	return variants, nil
+}
+
+// Key is the blob key of the variant of the original stored under key. It sits
+// next to the original so the two share a storage location.
+func (v Variant) Key(original string) string {
+	return strings.TrimSuffix(original, path.Ext(original)) + "_" + v.Name + extension(v.Format)
+}
+
+// Render scales img down to fit the variant; smaller images keep their size.
+func (v Variant) Render(img image.Image) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > v.MaxSize || height > v.MaxSize {
+		if width >= height {
+			width, height = v.MaxSize, max(1, height*v.MaxSize/width)
+		} else {
+			width, height = max(1, width*v.MaxSize/height), v.MaxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if v.Format == "jpeg" {
+		// JPEG has no alpha channel; flatten transparency onto white
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// Encode writes img in the variant's format.
+func (v Variant) Encode(w io.Writer, img image.Image) error {
+	switch v.Format {
+	case "jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case "png":
+		return png.Encode(w, img)
+	case "webp":
+		return nativewebp.Encode(w, img, nil)
+	default:
+		return errors.New("unsupported format " + v.Format)
+	}
+}
+
+// ContentType is the MIME type of format, or "" for an unsupported one.
+func ContentType(format string) string {
+	switch format {
+	case "jpeg":
+		return "image/jpeg"
+	case "png":
+		return "image/png"
+	case "webp":
+		return "image/webp"
+	default:
+		return ""
+	}
+}
+
+func extension(format string) string {
+	if format == "jpeg" {
+		return ".jpg"
+	}
+	return "." + format
+}
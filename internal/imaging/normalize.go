@@ -0,0 +1,196 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// uploadJPEGQuality is used when re-encoding uploaded JPEGs; it is higher than
+// the variants' so the stored original loses as little as possible.
+const uploadJPEGQuality = 92
+
+var (
+	ErrTooLarge    = errors.New("image dimensions are too large")
+	ErrUnsupported = errors.New("unsupported image format")
+)
+
+// Limits bounds the images that are decoded, so a small file that expands to
+// an enormous bitmap (a decompression bomb) is refused before decoding.
+type Limits struct {
+	// MaxDimension caps the width and the height in pixels.
+	MaxDimension int
+	// MaxPixels caps width x height.
+	MaxPixels int
+}
+
+// Check reads only the image header and fails with ErrTooLarge when the image
+// exceeds the limits. Zero limits are not enforced.
+func (l Limits) Check(data []byte) (image.Config, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return config, "", fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return config, "", ErrUnsupported
+	}
+	if l.MaxDimension > 0 && (config.Width > l.MaxDimension || config.Height > l.MaxDimension) {
+		return config, "", ErrTooLarge
+	}
+	if l.MaxPixels > 0 && config.Width*config.Height > l.MaxPixels {
+		return config, "", ErrTooLarge
+	}
+	return config, format, nil
+}
+
+// Decode decodes a JPEG, PNG or WebP image within the limits.
+func (l Limits) Decode(data []byte) (image.Image, error) {
+	if _, _, err := l.Check(data); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Normalized is an image upload without its metadata.
+type Normalized struct {
+	Data       []byte
+	Width      int
+	Height     int
+	CapturedAt *time.Time
+}
+
+// Normalize removes the EXIF, XMP, ICC and text metadata from an image upload.
+// JPEG and PNG files are re-encoded; a JPEG's EXIF orientation is applied to
+// the pixels first, since the tag that told viewers to rotate it is dropped,
+// and its capture time is returned. Every frame of a GIF is re-encoded, which
+// keeps animations but drops comment and application blocks. WebP files only
+// lose their metadata chunks.
+func (l Limits) Normalize(data []byte) (*Normalized, error) {
+	config, format, err := l.Check(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// GIF and WebP files are not decoded here, so their size comes from the
+	// header
+	out := Normalized{Width: config.Width, Height: config.Height}
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		var img image.Image
+		if img, err = decode(data); err != nil {
+			return nil, err
+		}
+		exif := ReadEXIF(data)
+		img = Orient(img, exif.Orientation)
+		out.Width, out.Height = img.Bounds().Dx(), img.Bounds().Dy()
+		out.CapturedAt = exif.CapturedAt
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: uploadJPEGQuality})
+	case "png":
+		var img image.Image
+		if img, err = decode(data); err != nil {
+			return nil, err
+		}
+		err = png.Encode(&buf, img)
+	case "gif":
+		err = l.normalizeGIF(&buf, data, config)
+	case "webp":
+		var stripped []byte
+		if stripped, err = stripWebP(data); err != nil {
+			err = fmt.Errorf("%w: %v", ErrUnsupported, err)
+		}
+		buf.Write(stripped)
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out.Data = buf.Bytes()
+	return &out, nil
+}
+
+func decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	return img, nil
+}
+
+// normalizeGIF re-encodes all frames of a GIF. Frames share the logical
+// screen, so the pixel limit applies to all of them together, and is checked
+// on the frame count before any frame is decoded.
+func (l Limits) normalizeGIF(w io.Writer, data []byte, config image.Config) error {
+	frames, err := gifFrames(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if l.MaxPixels > 0 && frames*config.Width*config.Height > l.MaxPixels {
+		return ErrTooLarge
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	return gif.EncodeAll(w, &gif.GIF{
+		Image:     anim.Image,
+		Delay:     anim.Delay,
+		LoopCount: anim.LoopCount,
+		Disposal:  anim.Disposal,
+		Config:    anim.Config,
+	})
+}
+
+// Orient turns img upright according to an EXIF orientation (1 to 8).
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// orientations 5 to 8 swap the axes
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise to view
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90° counter-clockwise to view
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,54 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// VP8X feature flags announcing the metadata chunks stripWebP removes.
+const (
+	vp8xICC  = 0x20
+	vp8xEXIF = 0x08
+	vp8xXMP  = 0x04
+)
+
+var errInvalidWebP = errors.New("invalid webp container")
+
+// stripWebP removes the EXIF, XMP and ICC profile chunks from a WebP file and
+// clears their flags, leaving the image data untouched. Re-encoding would
+// bloat lossy WebP, since only a lossless encoder is available.
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errInvalidWebP
+	}
+
+	out := append([]byte(nil), data[:12]...)
+	for pos := 12; pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, errInvalidWebP
+		}
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size%2 // chunks are padded to an even size
+		if size < 0 || end > len(data) {
+			return nil, errInvalidWebP
+		}
+
+		switch fourCC {
+		case "EXIF", "XMP ", "ICCP":
+			// dropped
+		case "VP8X":
+			chunk := append([]byte(nil), data[pos:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= vp8xICC | vp8xEXIF | vp8xXMP
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
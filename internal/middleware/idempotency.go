@@ -0,0 +1,270 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"log/slog"
+	"mastery-project/internal/config"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	maxIdempotencyKey = 255
+	// maxStoredResponse caps the response body kept for replay; larger responses
+	// are not remembered and the key is released instead.
+	maxStoredResponse = 1 << 20
+)
+
+// replayedHeaders are the response headers stored alongside the body.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location", "Content-Disposition"}
+
+type IdempotencyMiddleware struct {
+	Keys    *repository.IdempotencyRepository
+	ttl     time.Duration
+	maxBody int64
+}
+
+// NewIdempotencyMiddleware buffers bodies up to the larger of
+// cfg.MaxBodyBytes and maxBody, the largest body the handlers accept, so a
+// request the handler would take is never refused for its key.
+func NewIdempotencyMiddleware(keys *repository.IdempotencyRepository, cfg config.Idempotency, maxBody int64) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		Keys:    keys,
+		ttl:     time.Duration(cfg.TTLHours) * time.Hour,
+		maxBody: max(int64(cfg.MaxBodyBytes), maxBody),
+	}
+}
+
+// Handle makes mutating requests carrying an Idempotency-Key safe to retry. The
+// first request with a key is executed and its response stored; retries with the
+// same key and the same request get that response replayed, while reusing the
+// key for a different request is rejected with 409. It must run after Protected,
+// since keys are scoped to the user.
+func (m *IdempotencyMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		user, ok := UserFromContext(r.Context())
+		if key == "" || !ok || !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			writeError(w, http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY", "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, m.maxBody))
+		if err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", "request body too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &model.IdempotencyRecord{
+			UserID:      user.ID,
+			Key:         key,
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			Fingerprint: fingerprint(r, body),
+			ExpiresAt:   time.Now().Add(m.ttl),
+		}
+
+		existing, err := m.Keys.Reserve(r.Context(), record)
+		if err != nil {
+			slog.Error("idempotency", "err", err)
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "could not check Idempotency-Key")
+			return
+		}
+		if existing != nil {
+			m.replay(w, record, existing)
+			return
+		}
+
+		// the client may be gone by the time we store the response, which is exactly
+		// the case a retry needs it for
+		ctx := context.WithoutCancel(r.Context())
+
+		recorder := &recordingWriter{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				if err := m.Keys.Release(ctx, user.ID, key); err != nil {
+					slog.Error("idempotency", "err", err)
+				}
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		// server errors and oversized responses are not remembered, so a retry runs again
+		if recorder.status >= 500 || recorder.overflow {
+			return
+		}
+
+		headers := make(map[string][]string)
+		for _, name := range replayedHeaders {
+			if values := recorder.Header().Values(name); len(values) > 0 {
+				headers[name] = values
+			}
+		}
+		if err := m.Keys.Complete(ctx, user.ID, key, recorder.status, headers, recorder.body.Bytes()); err != nil {
+			slog.Error("idempotency", "err", err)
+			return
+		}
+		completed = true
+	})
+}
+
+func (m *IdempotencyMiddleware) replay(w http.ResponseWriter, request, existing *model.IdempotencyRecord) {
+	if existing.Method != request.Method || existing.Path != request.Path || existing.Fingerprint != request.Fingerprint {
+		writeError(w, http.StatusConflict, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used for a different request")
+		return
+	}
+	if existing.StatusCode == nil {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusConflict, "IDEMPOTENCY_KEY_IN_PROGRESS", "a request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	for name, values := range existing.Headers {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(*existing.StatusCode)
+	_, _ = w.Write(existing.Body)
+}
+
+// PurgeExpired deletes expired keys every interval until ctx is done.
+func (m *IdempotencyMiddleware) PurgeExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := m.Keys.PurgeExpired(ctx, now); err != nil && !errors.Is(err, context.Canceled) {
+				slog.Error("idempotency", "err", err)
+			}
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// fingerprint hashes the method, URL and body of a request. Multipart bodies are
+// hashed part by part so a retry that picks a new boundary still matches.
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	writeField(hash, []byte(r.Method))
+	writeField(hash, []byte(r.URL.RequestURI()))
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		partsHash := sha256.New()
+		if hashMultipart(partsHash, body, params["boundary"]) == nil {
+			hash.Write(partsHash.Sum(nil))
+			return hex.EncodeToString(hash.Sum(nil))
+		}
+	}
+
+	writeField(hash, body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func hashMultipart(hash hash.Hash, body []byte, boundary string) error {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		writeField(hash, []byte(part.FormName()))
+		writeField(hash, []byte(part.FileName()))
+		writeField(hash, content)
+	}
+}
+
+// writeField writes a length-prefixed value so field boundaries cannot be shifted.
+func writeField(hash hash.Hash, value []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(value)))
+	hash.Write(length[:])
+	hash.Write(value)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    code,
+		"message": message,
+	})
+}
+
+// recordingWriter passes the response through while keeping a copy for replay.
+type recordingWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.overflow {
+		if w.body.Len()+len(b) > maxStoredResponse {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
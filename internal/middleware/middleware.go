@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"log/slog"
+	"mastery-project/internal/model"
 	"mastery-project/internal/repository"
 	"net/http"
 )
@@ -23,6 +24,12 @@ type contextKey string
 
 const userContextKey contextKey = "user"
 
+// UserFromContext returns the user attached to the request by Protected.
+func UserFromContext(ctx context.Context) (*model.User, bool) {
+	user, ok := ctx.Value(userContextKey).(*model.User)
+	return user, ok && user != nil
+}
+
 func (m *AuthMiddleware) Protected(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -64,3 +71,15 @@ func (m *AuthMiddleware) Protected(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// Admin lets only admins through. It must run after Protected.
+func (m *AuthMiddleware) Admin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext(r.Context())
+		if !ok || !user.IsAdmin {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "admin access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
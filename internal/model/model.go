@@ -1,6 +1,8 @@
 package model
 
 import (
+	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,18 +13,220 @@ type User struct {
 	Name      string    `json:"name" validate:"required min=3"`
 	Email     string    `json:"email" validate:"required,email"`
 	Password  string    `json:"password" validate:"required,min=8"`
+	IsAdmin   bool      `json:"is_admin"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdateAt  time.Time `json:"update_at"`
 }
 
 type Item struct {
-	ID          uuid.UUID `json:"id"`
-	UserID      uuid.UUID `json:"user_id" validate:"required"`
-	Title       string    `json:"title" validate:"required"`
-	Description string    `json:"description" validate:"required"`
-	FilePath    string    `json:"file_path"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdateAt    time.Time `json:"update_at"`
+	ID            uuid.UUID         `json:"id"`
+	UserID        uuid.UUID         `json:"user_id" validate:"required"`
+	Title         string            `json:"title" validate:"required,max=255"`
+	Description   string            `json:"description" validate:"required"`
+	FilePath      string            `json:"file_path"`
+	FileChecksum  string            `json:"file_checksum"`
+	FileSize      int64             `json:"file_size"`
+	Visibility    string            `json:"visibility" validate:"required,oneof=private unlisted public"`
+	Metadata      Metadata          `json:"metadata"`
+	Version       int               `json:"version"`
+	ETag          string            `json:"etag"`
+	Attachments   []Attachment      `json:"attachments,omitempty"`
+	ImageVariants map[string]string `json:"image_variants,omitempty"`
+	Permission    string            `json:"permission,omitempty"`
+	Favorite      bool              `json:"favorite,omitempty"`
+	Position      string            `json:"position,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdateAt      time.Time         `json:"update_at"`
+}
+
+// ItemETag is the strong entity tag for an item at the given version.
+func ItemETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// Item visibility levels. Private items are only visible to their owner and the
+// users they are shared with; unlisted items are visible to anyone who knows
+// their id; public items are also listed in the public feed.
+const (
+	VisibilityPrivate  = "private"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPublic   = "public"
+)
+
+// Access levels a user can hold on an item, from least to most.
+const (
+	PermissionViewer = "viewer"
+	PermissionEditor = "editor"
+	PermissionOwner  = "owner"
+)
+
+var permissionRank = map[string]int{
+	PermissionViewer: 1,
+	PermissionEditor: 2,
+	PermissionOwner:  3,
+}
+
+// PermissionAllows reports whether holding permission have grants what need requires.
+func PermissionAllows(have, need string) bool {
+	return permissionRank[have] > 0 && permissionRank[have] >= permissionRank[need]
+}
+
+// ItemShare grants a user other than the owner access to an item.
+type ItemShare struct {
+	ItemID     uuid.UUID `json:"item_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdateAt   time.Time `json:"update_at"`
+}
+
+type ShareItemRequest struct {
+	Email      string `json:"email" validate:"required,email"`
+	Permission string `json:"permission" validate:"required,oneof=viewer editor"`
+}
+
+// ItemLink is a public link that shows an item to anyone holding its token.
+// Only a hash of the token is stored, so Token is set just once, on creation.
+type ItemLink struct {
+	ID           uuid.UUID  `json:"id"`
+	ItemID       uuid.UUID  `json:"item_id"`
+	CreatedBy    *uuid.UUID `json:"created_by"`
+	Token        string     `json:"token,omitempty"`
+	URL          string     `json:"url,omitempty"`
+	PasswordHash string     `json:"-"`
+	HasPassword  bool       `json:"has_password"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	MaxViews     *int       `json:"max_views"`
+	ViewCount    int        `json:"view_count"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	LastViewedAt *time.Time `json:"last_viewed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Usable reports whether the link can still be opened at now.
+func (l *ItemLink) Usable(now time.Time) bool {
+	if l.RevokedAt != nil {
+		return false
+	}
+	if l.ExpiresAt != nil && !now.Before(*l.ExpiresAt) {
+		return false
+	}
+	return l.MaxViews == nil || l.ViewCount < *l.MaxViews
+}
+
+type CreateLinkRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxViews  *int       `json:"max_views" validate:"omitempty,min=1"`
+	Password  string     `json:"password" validate:"omitempty,min=4,max=72"`
+}
+
+// Collection groups items; collections nest through ParentID.
+type Collection struct {
+	ID          uuid.UUID    `json:"id"`
+	UserID      uuid.UUID    `json:"user_id"`
+	ParentID    *uuid.UUID   `json:"parent_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	CoverItemID *uuid.UUID   `json:"cover_item_id"`
+	ItemCount   int          `json:"item_count"`
+	Children    []Collection `json:"children,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdateAt    time.Time    `json:"update_at"`
+}
+
+type CreateCollection struct {
+	Name        string     `json:"name" validate:"required,max=255"`
+	Description string     `json:"description"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	CoverItemID *uuid.UUID `json:"cover_item_id"`
+}
+
+// UpdateCollection changes the fields present in the body; ParentID and
+// CoverItemID can also be set to null to move a collection to the top level or
+// clear its cover.
+type UpdateCollection struct {
+	Name        *string      `json:"name" validate:"omitempty,min=1,max=255"`
+	Description *string      `json:"description"`
+	ParentID    OptionalUUID `json:"parent_id"`
+	CoverItemID OptionalUUID `json:"cover_item_id"`
+}
+
+// OptionalUUID tells a JSON member that is absent (Set is false) apart from one
+// that is null (Set is true and Value is nil).
+type OptionalUUID struct {
+	Set   bool
+	Value *uuid.UUID
+}
+
+func (o *OptionalUUID) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	var id uuid.UUID
+	if err := json.Unmarshal(data, &id); err != nil {
+		return err
+	}
+	o.Value = &id
+	return nil
+}
+
+type CollectionItemsRequest struct {
+	ItemIDs []uuid.UUID `json:"item_ids" validate:"required,min=1,max=500"`
+}
+
+type MoveItemsRequest struct {
+	ItemIDs []uuid.UUID `json:"item_ids" validate:"required,min=1,max=500"`
+	To      uuid.UUID   `json:"to" validate:"required"`
+}
+
+// CollectionItemsPage is one page of a collection's items.
+type CollectionItemsPage struct {
+	Items  []Item `json:"items"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// Comment is a comment on an item. Top-level comments carry their replies;
+// replies cannot be replied to.
+type Comment struct {
+	ID         uuid.UUID  `json:"id"`
+	ItemID     uuid.UUID  `json:"item_id"`
+	UserID     *uuid.UUID `json:"user_id"`
+	AuthorName string     `json:"author_name"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+	Body       string     `json:"body"`
+	Mentions   []Mention  `json:"mentions"`
+	Replies    []Comment  `json:"replies,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdateAt   time.Time  `json:"update_at"`
+}
+
+// Mention is a user referenced as @name in a comment.
+type Mention struct {
+	UserID uuid.UUID `json:"user_id"`
+	Name   string    `json:"name"`
+}
+
+type CreateComment struct {
+	Body     string     `json:"body" validate:"required,max=5000"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+type UpdateComment struct {
+	Body string `json:"body" validate:"required,max=5000"`
+}
+
+// CommentsPage is one page of an item's top-level comments.
+type CommentsPage struct {
+	Comments []Comment `json:"comments"`
+	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
 }
 
 type Session struct {
@@ -34,10 +238,264 @@ type Session struct {
 	UpdateAt  time.Time `json:"update_at"`
 }
 
+// Attachment is a file attached to an item, listed in Position order.
+type Attachment struct {
+	ID           uuid.UUID `json:"id"`
+	ItemID       uuid.UUID `json:"item_id"`
+	Position     int       `json:"position"`
+	OriginalName string    `json:"original_name"`
+	FileName     string    `json:"file_name"`
+	Size         int64     `json:"size"`
+	MimeType     string    `json:"mime_type"`
+	Checksum     string    `json:"checksum"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ImageVariant is a resized rendition of an uploaded image, stored as its own
+// blob next to the original.
+type ImageVariant struct {
+	FilePath  string
+	Name      string
+	FileName  string
+	Width     int
+	Height    int
+	Size      int64
+	MimeType  string
+	Checksum  string
+	CreatedAt time.Time
+}
+
+// ImageVariantURL is where the named variant of the item's image is served.
+func ImageVariantURL(itemID uuid.UUID, name string) string {
+	return "/api/v1/items/" + itemID.String() + "/image?size=" + name
+}
+
+// Upload is a resumable (tus) upload. Its data is stored as chunk blobs, one
+// per PATCH, until an item uses it or it expires.
+type Upload struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	FileName string
+	Length   int64
+	Offset   int64
+	// Metadata is the Upload-Metadata header the upload was created with.
+	Metadata  string
+	Chunks    []string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Complete reports whether all of the upload's bytes have arrived.
+func (u *Upload) Complete() bool {
+	return u.Offset == u.Length
+}
+
+// UploadURL is where the resumable upload is continued.
+func UploadURL(id uuid.UUID) string {
+	return "/api/v1/uploads/" + id.String()
+}
+
+// ItemRevision is an immutable snapshot of an item's content taken on every change.
+type ItemRevision struct {
+	ID          uuid.UUID  `json:"id"`
+	ItemID      uuid.UUID  `json:"item_id"`
+	Revision    int        `json:"revision"`
+	AuthorID    *uuid.UUID `json:"author_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	// Visibility and Metadata are nil for revisions recorded before they
+	// were snapshotted.
+	Visibility    *string   `json:"visibility"`
+	Metadata      Metadata  `json:"metadata"`
+	ChangedFields []string  `json:"changed_fields"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// IdempotencyRecord remembers a mutating request sent with an Idempotency-Key
+// and, once it finished, the response to replay for retries.
+type IdempotencyRecord struct {
+	UserID      uuid.UUID           `json:"user_id"`
+	Key         string              `json:"key"`
+	Method      string              `json:"method"`
+	Path        string              `json:"path"`
+	Fingerprint string              `json:"fingerprint"`
+	StatusCode  *int                `json:"status_code"`
+	Headers     map[string][]string `json:"headers"`
+	Body        []byte              `json:"-"`
+	CreatedAt   time.Time           `json:"created_at"`
+	ExpiresAt   time.Time           `json:"expires_at"`
+}
+
 // Request and Response Models
+
+// Metadata holds an item's custom attributes, stored as a JSON object.
+type Metadata map[string]any
+
+// MetaFilter restricts item listings to items whose metadata value at Path
+// equals Value (Op "eq"), or compares numerically to it (gt, gte, lt, lte).
+type MetaFilter struct {
+	Path  []string
+	Op    string
+	Value string
+}
+
+// ItemOverrides changes fields of an item being duplicated or created from a
+// template. Metadata is merged key by key over the source's; a null value
+// removes the key.
+type ItemOverrides struct {
+	Title       *string  `json:"title" validate:"omitempty,min=1,max=255"`
+	Description *string  `json:"description" validate:"omitempty,min=1"`
+	Visibility  *string  `json:"visibility" validate:"omitempty,oneof=private unlisted public"`
+	Metadata    Metadata `json:"metadata"`
+}
+
+// Apply writes the overrides onto item.
+func (o ItemOverrides) Apply(item *Item) {
+	if o.Title != nil {
+		item.Title = *o.Title
+	}
+	if o.Description != nil {
+		item.Description = *o.Description
+	}
+	if o.Visibility != nil {
+		item.Visibility = *o.Visibility
+	}
+	if len(o.Metadata) == 0 {
+		return
+	}
+	merged := make(Metadata, len(item.Metadata)+len(o.Metadata))
+	for key, value := range item.Metadata {
+		merged[key] = value
+	}
+	for key, value := range o.Metadata {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	item.Metadata = merged
+}
+
+// ItemTemplate is a reusable set of item fields a user saved from an item.
+type ItemTemplate struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Visibility  string    `json:"visibility"`
+	Metadata    Metadata  `json:"metadata"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdateAt    time.Time `json:"update_at"`
+}
+
+type SaveTemplateRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// Orders GET /items supports: newest first, or the user's own arrangement.
+const (
+	SortCreatedAt = "created_at"
+	SortPosition  = "position"
+)
+
+// ItemQuery narrows and orders GET /items.
+type ItemQuery struct {
+	Meta     []MetaFilter
+	Favorite bool
+	Sort     string
+}
+
+// MoveItemRequest places an item directly after or before another item of the
+// user's list; with neither set the item moves to the top.
+type MoveItemRequest struct {
+	After  *uuid.UUID `json:"after" validate:"excluded_with=Before"`
+	Before *uuid.UUID `json:"before"`
+}
+
+// UpdateItem carries a partial update; nil fields are left as they are.
 type UpdateItem struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title       *string `json:"title" validate:"omitempty,min=1,max=255"`
+	Description *string `json:"description" validate:"omitempty,min=1"`
+	Visibility  *string `json:"visibility" validate:"omitempty,oneof=private unlisted public"`
+	// Metadata replaces the item's metadata as a whole; nil leaves it unchanged.
+	Metadata Metadata `json:"metadata"`
+}
+
+type ReorderAttachmentsRequest struct {
+	IDs []uuid.UUID `json:"ids" validate:"required,min=1"`
+}
+
+type BulkRequest struct {
+	// Mode is "atomic" (all-or-nothing, the default) or "best_effort".
+	Mode       string          `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	Operations []BulkOperation `json:"operations" validate:"required,min=1"`
+}
+
+type BulkOperation struct {
+	Op          string     `json:"op" validate:"required,oneof=create update delete"`
+	ID          *uuid.UUID `json:"id" validate:"required_unless=Op create"`
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	// Version, when set, makes an update or delete conditional like If-Match.
+	Version int `json:"version" validate:"min=0"`
+	// Index is the operation's position in the request.
+	Index int `json:"-"`
+}
+
+type BulkResult struct {
+	Index   int        `json:"index"`
+	Op      string     `json:"op"`
+	ID      *uuid.UUID `json:"id,omitempty"`
+	Status  int        `json:"status"`
+	Version int        `json:"version,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Mode      string       `json:"mode"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// ImportRow is one record of an import file; Line is its 1-based line (or
+// element) number in that file.
+type ImportRow struct {
+	Line        int
+	Title       string
+	Description string
+}
+
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	DryRun   bool          `json:"dry_run"`
+	Total    int           `json:"total"`
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []ImportError `json:"errors"`
+}
+
+type ImportJob struct {
+	ID         uuid.UUID     `json:"id"`
+	UserID     uuid.UUID     `json:"user_id"`
+	Format     string        `json:"format"`
+	Status     string        `json:"status"`
+	Total      int           `json:"total"`
+	Processed  int           `json:"processed"`
+	Imported   int           `json:"imported"`
+	Failed     int           `json:"failed"`
+	Errors     []ImportError `json:"errors"`
+	Error      string        `json:"error,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	FinishedAt *time.Time    `json:"finished_at"`
 }
 
 type CreateUserRequest struct {
@@ -56,3 +514,41 @@ type UserResponse struct {
 	Name  string    `json:"name" validate:"required"`
 	Email string    `json:"email" validate:"required,email"`
 }
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type RevisionDiff struct {
+	ItemID  uuid.UUID     `json:"item_id"`
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// Quota caps the file bytes and the number of items a user's items may take
+// up; zero is unlimited.
+type Quota struct {
+	MaxBytes int64 `json:"max_bytes"`
+	MaxItems int   `json:"max_items"`
+}
+
+// QuotaOverride is an admin's per-user replacement for the configured quota;
+// a nil field keeps the configured value.
+type QuotaOverride struct {
+	MaxBytes *int64 `json:"max_bytes" validate:"omitempty,min=0"`
+	MaxItems *int   `json:"max_items" validate:"omitempty,min=0"`
+}
+
+// Usage is what a user's items take up: the bytes of their images and
+// attachments, counted for every item even where files are shared, and the
+// number of items. Quota is the limit that applies after Override.
+type Usage struct {
+	UserID   uuid.UUID     `json:"user_id"`
+	Bytes    int64         `json:"bytes"`
+	Items    int           `json:"items"`
+	Quota    Quota         `json:"quota"`
+	Override QuotaOverride `json:"override"`
+}
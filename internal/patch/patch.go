@@ -0,0 +1,309 @@
+// Package patch applies RFC 7396 JSON Merge Patch and RFC 6902 JSON Patch
+// documents to decoded JSON values (map[string]any, []any and scalars).
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch means the patch document itself is malformed.
+	ErrInvalidPatch = errors.New("invalid patch document")
+	// ErrCannotApply means the patch is well formed but does not fit the target.
+	ErrCannotApply = errors.New("patch cannot be applied")
+	// ErrTestFailed means a JSON Patch "test" operation did not match.
+	ErrTestFailed = errors.New("patch test operation failed")
+)
+
+// MergePatch applies an RFC 7396 merge patch to doc and returns the result.
+func MergePatch(doc any, data []byte) (any, error) {
+	var p any
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+	return mergeValue(doc, p), nil
+}
+
+func mergeValue(target, p any) any {
+	patchObj, ok := p.(map[string]any)
+	if !ok {
+		return p
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	} else {
+		targetObj = cloneObject(targetObj)
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
+
+type operation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies an RFC 6902 JSON Patch to doc. The operations are applied
+// in order to a copy of doc; if any of them fails, doc is left untouched.
+func JSONPatch(doc any, data []byte) (any, error) {
+	var ops []operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+
+	result := deepCopy(doc)
+	for i, op := range ops {
+		if op.Path == nil {
+			return nil, fmt.Errorf("%w: operation %d has no path", ErrInvalidPatch, i)
+		}
+		path, err := parsePointer(*op.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d has no value", ErrInvalidPatch, i)
+			}
+			var value any
+			if err := json.Unmarshal(*op.Value, &value); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+			}
+			switch op.Op {
+			case "add":
+				result, err = add(result, path, value)
+			case "replace":
+				if result, _, err = remove(result, path); err == nil {
+					result, err = add(result, path, value)
+				}
+			case "test":
+				var current any
+				if current, err = get(result, path); err == nil && !reflect.DeepEqual(current, value) {
+					err = fmt.Errorf("%w: %s", ErrTestFailed, *op.Path)
+				}
+			}
+		case "remove":
+			result, _, err = remove(result, path)
+		case "move", "copy":
+			if op.From == nil {
+				return nil, fmt.Errorf("%w: operation %d has no from", ErrInvalidPatch, i)
+			}
+			from, ferr := parsePointer(*op.From)
+			if ferr != nil {
+				return nil, ferr
+			}
+			var value any
+			if op.Op == "move" {
+				if isPrefix(from, path) && len(from) < len(path) {
+					return nil, fmt.Errorf("%w: cannot move %s into itself", ErrCannotApply, *op.From)
+				}
+				result, value, err = remove(result, from)
+			} else {
+				value, err = get(result, from)
+				value = deepCopy(value)
+			}
+			if err == nil {
+				result, err = add(result, path, value)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: invalid pointer %q", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	current := doc
+	for _, token := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path /%s does not exist", ErrCannotApply, strings.Join(path, "/"))
+			}
+			current = value
+		case []any:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%w: path /%s does not exist", ErrCannotApply, strings.Join(path, "/"))
+		}
+	}
+	return current, nil
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+
+	return update(doc, parentPath, func(parent any) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[last] = value
+			return node, nil
+		case []any:
+			index := len(node)
+			if last != "-" {
+				var err error
+				if index, err = arrayIndex(last, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("%w: cannot add to /%s", ErrCannotApply, strings.Join(parentPath, "/"))
+		}
+	})
+}
+
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+
+	var removed any
+	result, err := update(doc, parentPath, func(parent any) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			value, ok := node[last]
+			if !ok {
+				return nil, fmt.Errorf("%w: path /%s does not exist", ErrCannotApply, strings.Join(path, "/"))
+			}
+			removed = value
+			delete(node, last)
+			return node, nil
+		case []any:
+			index, err := arrayIndex(last, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return append(node[:index], node[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%w: path /%s does not exist", ErrCannotApply, strings.Join(path, "/"))
+		}
+	})
+	return result, removed, err
+}
+
+// update walks to the container at path and replaces it with fn's result.
+func update(doc any, path []string, fn func(any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return fn(doc)
+	}
+	child, err := get(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = update(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	switch node := doc.(type) {
+	case map[string]any:
+		node[path[0]] = child
+	case []any:
+		index, _ := arrayIndex(path[0], len(node)-1)
+		node[index] = child
+	}
+	return doc, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrCannotApply, token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, fmt.Errorf("%w: array index %q out of range", ErrCannotApply, token)
+	}
+	return index, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneObject(obj map[string]any) map[string]any {
+	clone := make(map[string]any, len(obj))
+	for key, value := range obj {
+		clone[key] = value
+	}
+	return clone
+}
+
+func deepCopy(value any) any {
+	switch node := value.(type) {
+	case map[string]any:
+		clone := make(map[string]any, len(node))
+		for key, child := range node {
+			clone[key] = deepCopy(child)
+		}
+		return clone
+	case []any:
+		clone := make([]any, len(node))
+		for i, child := range node {
+			clone[i] = deepCopy(child)
+		}
+		return clone
+	default:
+		return value
+	}
+}
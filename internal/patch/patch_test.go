@@ -0,0 +1,143 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, data string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	return v
+}
+
+// The examples of RFC 7396, Appendix A.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.doc+" + "+tt.patch, func(t *testing.T) {
+			doc := decode(t, tt.doc)
+			got, err := MergePatch(doc, []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(doc, decode(t, tt.doc)) {
+				t.Errorf("document was modified: %v", doc)
+			}
+		})
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	if _, err := MergePatch(map[string]any{}, []byte(`{"a":`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Fatalf("got %v, want ErrInvalidPatch", err)
+	}
+}
+
+// Mostly the examples of RFC 6902, Appendix A.
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append to array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"add nested member", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"add replaces existing member", `{"foo":"bar"}`, `[{"op":"add","path":"/foo","value":1}]`, `{"foo":1}`},
+		{"add whole document", `{"foo":"bar"}`, `[{"op":"add","path":"","value":[1]}]`, `[1]`},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy value", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"foo":{"bar":1},"baz":{"bar":1}}`},
+		{"test passes", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"escaped pointer", `{"/":1,"~":2}`, `[{"op":"replace","path":"/~1","value":3},{"op":"remove","path":"/~0"}]`, `{"/":3}`},
+		{"operations apply in order", `{}`, `[{"op":"add","path":"/a","value":[]},{"op":"add","path":"/a/-","value":1},{"op":"copy","from":"/a","path":"/b"},{"op":"add","path":"/b/0","value":0}]`, `{"a":[1],"b":[0,1]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decode(t, tt.doc)
+			got, err := JSONPatch(doc, []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("JSONPatch: %v", err)
+			}
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(doc, decode(t, tt.doc)) {
+				t.Errorf("document was modified: %v", doc)
+			}
+		})
+	}
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+		want             error
+	}{
+		{"not an array", `{}`, `{"op":"add"}`, ErrInvalidPatch},
+		{"missing path", `{}`, `[{"op":"add","value":1}]`, ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, ErrInvalidPatch},
+		{"missing from", `{}`, `[{"op":"move","path":"/a"}]`, ErrInvalidPatch},
+		{"unknown operation", `{}`, `[{"op":"merge","path":"/a","value":1}]`, ErrInvalidPatch},
+		{"pointer without slash", `{}`, `[{"op":"add","path":"a","value":1}]`, ErrInvalidPatch},
+		{"remove missing member", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, ErrCannotApply},
+		{"replace missing member", `{"a":1}`, `[{"op":"replace","path":"/b","value":2}]`, ErrCannotApply},
+		{"add to missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, ErrCannotApply},
+		{"add past end of array", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`, ErrCannotApply},
+		{"leading zero index", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`, ErrCannotApply},
+		{"negative index", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/-1"}]`, ErrCannotApply},
+		{"add to scalar", `{"a":1}`, `[{"op":"add","path":"/a/b","value":1}]`, ErrCannotApply},
+		{"move into itself", `{"a":{"b":{}}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, ErrCannotApply},
+		{"test fails", `{"a":"b"}`, `[{"op":"test","path":"/a","value":"c"}]`, ErrTestFailed},
+		{"test on missing path", `{"a":"b"}`, `[{"op":"test","path":"/b","value":"b"}]`, ErrCannotApply},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := JSONPatch(decode(t, tt.doc), []byte(tt.patch)); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// A failing operation leaves the document as it was, even after earlier
+// operations succeeded.
+func TestJSONPatchAtomic(t *testing.T) {
+	doc := decode(t, `{"a":[1,2],"b":{"c":1}}`)
+	patch := `[{"op":"remove","path":"/a/0"},{"op":"add","path":"/b/d","value":2},{"op":"test","path":"/b/c","value":2}]`
+	if _, err := JSONPatch(doc, []byte(patch)); !errors.Is(err, ErrTestFailed) {
+		t.Fatalf("got %v, want ErrTestFailed", err)
+	}
+	if want := decode(t, `{"a":[1,2],"b":{"c":1}}`); !reflect.DeepEqual(doc, want) {
+		t.Fatalf("document was modified: %v", doc)
+	}
+}
@@ -0,0 +1,97 @@
+// Package rank generates fractional ranking keys: strings that sort in the
+// intended order under byte-wise comparison (COLLATE "C"), where a key can
+// always be generated between any two others, so moving an entry only rewrites
+// that entry's key.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+// digits are the key characters in ascending byte order.
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ErrInvalidRange means no key fits between the given bounds.
+var ErrInvalidRange = errors.New("rank: invalid key range")
+
+// Between returns a key that sorts after a and before b. An empty a means the
+// start of the list and an empty b its end. Keys must only use digits and must
+// not end in '0', which every key this package returns satisfies.
+func Between(a, b string) (string, error) {
+	if !valid(a) || !valid(b) || (b != "" && a >= b) {
+		return "", ErrInvalidRange
+	}
+	return midpoint(a, b), nil
+}
+
+// N returns n ascending keys between a and b, spread so that their length
+// grows with log n rather than n.
+func N(a, b string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	mid, err := Between(a, b)
+	if err != nil {
+		return nil, err
+	}
+	before, err := N(a, mid, (n-1)/2)
+	if err != nil {
+		return nil, err
+	}
+	after, err := N(mid, b, n-1-(n-1)/2)
+	if err != nil {
+		return nil, err
+	}
+	keys := append(before, mid)
+	return append(keys, after...), nil
+}
+
+func midpoint(a, b string) string {
+	if b != "" {
+		// keep the common prefix, reading missing characters of a as '0'
+		n := 0
+		for n < len(b) && digitAt(a, n) == strings.IndexByte(digits, b[n]) {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(a[min(n, len(a)):], b[n:])
+		}
+	}
+
+	lo := digitAt(a, 0)
+	hi := len(digits)
+	if b != "" {
+		hi = strings.IndexByte(digits, b[0])
+	}
+	if hi-lo > 1 {
+		return string(digits[(lo+hi+1)/2])
+	}
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[lo]) + midpoint(rest, "")
+}
+
+func digitAt(key string, i int) int {
+	if i >= len(key) {
+		return 0
+	}
+	return strings.IndexByte(digits, key[i])
+}
+
+func valid(key string) bool {
+	if strings.HasSuffix(key, "0") {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
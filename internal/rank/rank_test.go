@@ -0,0 +1,174 @@
+package rank
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"empty list", "", ""},
+		{"before first", "", "V"},
+		{"after last", "V", ""},
+		{"adjacent digits", "A", "B"},
+		{"common prefix", "AB", "AC"},
+		{"prefix of upper bound", "A", "A1"},
+		{"shorter upper bound", "Az", "B"},
+		{"before smallest key", "", "1"},
+		{"after largest digit", "z", ""},
+		{"deep prefix", "Vzzz", "W"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := Between(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("Between(%q, %q): %v", tt.a, tt.b, err)
+			}
+			assertBetween(t, tt.a, tt.b, key)
+		})
+	}
+}
+
+func TestBetweenInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"equal bounds", "A", "A"},
+		{"reversed bounds", "B", "A"},
+		{"trailing zero", "A0", ""},
+		{"invalid character", "A-", ""},
+		{"invalid upper bound", "", "A_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Between(tt.a, tt.b); !errors.Is(err, ErrInvalidRange) {
+				t.Fatalf("Between(%q, %q) = %v, want ErrInvalidRange", tt.a, tt.b, err)
+			}
+		})
+	}
+}
+
+// Inserting repeatedly at the same spot must keep producing valid keys.
+func TestBetweenRepeated(t *testing.T) {
+	tests := []struct {
+		name  string
+		where string
+	}{
+		{"always at the front", "front"},
+		{"always at the end", "end"},
+		{"always after the first", "after first"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, _ := Between("", "")
+			second, _ := Between(first, "")
+			for i := 0; i < 500; i++ {
+				a, b := first, second
+				switch tt.where {
+				case "front":
+					a, b = "", first
+				case "end":
+					a, b = second, ""
+				}
+				key, err := Between(a, b)
+				if err != nil {
+					t.Fatalf("step %d: Between(%q, %q): %v", i, a, b, err)
+				}
+				assertBetween(t, a, b, key)
+				switch tt.where {
+				case "front":
+					first = key
+				default:
+					second = key
+				}
+			}
+		})
+	}
+}
+
+func TestN(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   string
+		n      int
+		maxLen int
+	}{
+		{"none", "", "", 0, 0},
+		{"one", "", "", 1, 1},
+		{"a few", "A", "B", 5, 3},
+		{"many", "", "", 1000, 3},
+		{"many in a narrow range", "A", "A1", 1000, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := N(tt.a, tt.b, tt.n)
+			if err != nil {
+				t.Fatalf("N: %v", err)
+			}
+			if len(keys) != tt.n {
+				t.Fatalf("got %d keys, want %d", len(keys), tt.n)
+			}
+			prev := tt.a
+			for _, key := range keys {
+				assertBetween(t, prev, tt.b, key)
+				if len(key) > tt.maxLen {
+					t.Errorf("key %q is longer than %d", key, tt.maxLen)
+				}
+				prev = key
+			}
+		})
+	}
+}
+
+func TestNInvalid(t *testing.T) {
+	if _, err := N("B", "A", 3); !errors.Is(err, ErrInvalidRange) {
+		t.Fatalf("N with reversed bounds = %v, want ErrInvalidRange", err)
+	}
+}
+
+// Random moves keep every key valid and the list in order.
+func TestRandomMoves(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	keys, err := N("", "", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2000; i++ {
+		from, to := rng.Intn(len(keys)), rng.Intn(len(keys)-1)
+		rest := append(append([]string{}, keys[:from]...), keys[from+1:]...)
+		a, b := "", ""
+		if to > 0 {
+			a = rest[to-1]
+		}
+		if to < len(rest) {
+			b = rest[to]
+		}
+		key, err := Between(a, b)
+		if err != nil {
+			t.Fatalf("move %d: Between(%q, %q): %v", i, a, b, err)
+		}
+		keys = append(rest[:to], append([]string{key}, rest[to:]...)...)
+		if !sort.StringsAreSorted(keys) {
+			t.Fatalf("move %d: keys out of order: %q", i, keys)
+		}
+	}
+}
+
+func assertBetween(t *testing.T, a, b, key string) {
+	t.Helper()
+	if !valid(key) || key == "" {
+		t.Fatalf("key %q is not valid", key)
+	}
+	if a != "" && key <= a {
+		t.Fatalf("key %q does not sort after %q", key, a)
+	}
+	if b != "" && key >= b {
+		t.Fatalf("key %q does not sort before %q", key, b)
+	}
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PinBlob records a stored file, or pins one that exists already, so it is
+// not deleted before the row that will reference it is saved. Every pin must
+// be undone with UnpinBlob.
+func (ir *ItemRepository) PinBlob(ctx context.Context, key, checksum string, size int64) error {
+	sql := `
+		INSERT INTO blobs (key, checksum, size, pins)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (key) DO UPDATE SET pins = blobs.pins + 1, updated_at = NOW()
+	`
+	if _, err := ir.db.Exec(ctx, sql, key, checksum, size); err != nil {
+		return fmt.Errorf("pin blob: %w", err)
+	}
+	return nil
+}
+
+func (ir *ItemRepository) UnpinBlob(ctx context.Context, key string) error {
+	sql := `UPDATE blobs SET pins = pins - 1, updated_at = NOW() WHERE key = $1 AND pins > 0`
+	if _, err := ir.db.Exec(ctx, sql, key); err != nil {
+		return fmt.Errorf("unpin blob: %w", err)
+	}
+	return nil
+}
+
+// ReleaseBlob calls remove and forgets the file when nothing references or
+// pins it any more, and reports whether it did. The file's row stays locked
+// while remove runs, so a concurrent upload of the same content waits and then
+// stores it again instead of pinning a file that is being deleted.
+func (ir *ItemRepository) ReleaseBlob(ctx context.Context, key string, remove func(context.Context) error) (bool, error) {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("release blob: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var refs, pins int
+	err = tx.QueryRow(ctx, `SELECT refs, pins FROM blobs WHERE key = $1 FOR UPDATE`, key).Scan(&refs, &pins)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("release blob: %w", err)
+	}
+	if refs > 0 || pins > 0 {
+		return false, nil
+	}
+
+	if err := remove(ctx); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM blobs WHERE key = $1`, key); err != nil {
+		return false, fmt.Errorf("release blob: %w", err)
+	}
+	return true, tx.Commit(ctx)
+}
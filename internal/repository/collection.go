@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrParentNotFound     = errors.New("parent collection not found")
+	ErrCollectionCycle    = errors.New("a collection cannot be moved into itself or one of its descendants")
+	ErrNotInCollection    = errors.New("item is not in the collection")
+)
+
+const collectionColumns = `
+	c.id, c.user_id, c.parent_id, c.name, c.description, c.cover_item_id,
+	(SELECT COUNT(*) FROM collection_items ci WHERE ci.collection_id = c.id),
+	c.created_at, c.updated_at`
+
+type CollectionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCollectionRepository(db *pgxpool.Pool) *CollectionRepository {
+	return &CollectionRepository{db: db}
+}
+
+func scanCollection(row pgx.Row) (*model.Collection, error) {
+	var collection model.Collection
+	err := row.Scan(
+		&collection.ID,
+		&collection.UserID,
+		&collection.ParentID,
+		&collection.Name,
+		&collection.Description,
+		&collection.CoverItemID,
+		&collection.ItemCount,
+		&collection.CreatedAt,
+		&collection.UpdateAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (cr *CollectionRepository) Create(ctx context.Context, collection *model.Collection) error {
+	tx, err := cr.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create collection: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if collection.ParentID != nil {
+		if err := lockCollection(ctx, tx, *collection.ParentID, collection.UserID); err != nil {
+			if errors.Is(err, ErrCollectionNotFound) {
+				return ErrParentNotFound
+			}
+			return err
+		}
+	}
+	if collection.CoverItemID != nil {
+		if err := checkVisible(ctx, tx, []uuid.UUID{*collection.CoverItemID}, collection.UserID); err != nil {
+			return err
+		}
+	}
+
+	sql := `
+		INSERT INTO collections (user_id, parent_id, name, description, cover_item_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, sql,
+		collection.UserID,
+		collection.ParentID,
+		collection.Name,
+		collection.Description,
+		collection.CoverItemID,
+	).Scan(&collection.ID, &collection.CreatedAt, &collection.UpdateAt)
+	if err != nil {
+		return fmt.Errorf("create collection: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// Get returns one of userID's collections together with its direct children.
+func (cr *CollectionRepository) Get(ctx context.Context, id string, userID uuid.UUID) (*model.Collection, error) {
+	sql := `SELECT ` + collectionColumns + ` FROM collections c WHERE c.id = $1 AND c.user_id = $2`
+
+	collection, err := scanCollection(cr.db.QueryRow(ctx, sql, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, fmt.Errorf("get collection: %w", err)
+	}
+
+	collection.Children, err = cr.list(ctx, `c.user_id = $1 AND c.parent_id = $2`, userID, collection.ID)
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// List returns userID's collections. With parentID set only its direct children
+// are returned; topLevel returns only collections without a parent.
+func (cr *CollectionRepository) List(ctx context.Context, userID uuid.UUID, parentID *uuid.UUID, topLevel bool) ([]model.Collection, error) {
+	switch {
+	case parentID != nil:
+		return cr.list(ctx, `c.user_id = $1 AND c.parent_id = $2`, userID, *parentID)
+	case topLevel:
+		return cr.list(ctx, `c.user_id = $1 AND c.parent_id IS NULL`, userID)
+	default:
+		return cr.list(ctx, `c.user_id = $1`, userID)
+	}
+}
+
+func (cr *CollectionRepository) list(ctx context.Context, where string, args ...any) ([]model.Collection, error) {
+	sql := `SELECT ` + collectionColumns + ` FROM collections c WHERE ` + where + ` ORDER BY c.name, c.id`
+
+	rows, err := cr.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := []model.Collection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list collections: %w", err)
+		}
+		collections = append(collections, *collection)
+	}
+	return collections, rows.Err()
+}
+
+// Update writes the fields set in update. Moving a collection checks that the
+// new parent is one of the user's collections and not the collection itself or
+// one of its descendants.
+func (cr *CollectionRepository) Update(ctx context.Context, id uuid.UUID, userID uuid.UUID, update model.UpdateCollection) error {
+	tx, err := cr.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("update collection: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCollection(ctx, tx, id, userID); err != nil {
+		return err
+	}
+
+	args := []any{id}
+	var set []string
+	if update.Name != nil {
+		args = append(args, *update.Name)
+		set = append(set, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if update.Description != nil {
+		args = append(args, *update.Description)
+		set = append(set, fmt.Sprintf("description = $%d", len(args)))
+	}
+	if update.ParentID.Set {
+		if parentID := update.ParentID.Value; parentID != nil {
+			if err := lockCollection(ctx, tx, *parentID, userID); err != nil {
+				if errors.Is(err, ErrCollectionNotFound) {
+					return ErrParentNotFound
+				}
+				return err
+			}
+			if err := checkNoCycle(ctx, tx, id, *parentID); err != nil {
+				return err
+			}
+		}
+		args = append(args, update.ParentID.Value)
+		set = append(set, fmt.Sprintf("parent_id = $%d", len(args)))
+	}
+	if update.CoverItemID.Set {
+		if coverID := update.CoverItemID.Value; coverID != nil {
+			if err := checkVisible(ctx, tx, []uuid.UUID{*coverID}, userID); err != nil {
+				return err
+			}
+		}
+		args = append(args, update.CoverItemID.Value)
+		set = append(set, fmt.Sprintf("cover_item_id = $%d", len(args)))
+	}
+	if len(set) == 0 {
+		return tx.Commit(ctx)
+	}
+
+	sql := `UPDATE collections SET ` + strings.Join(set, ", ") + `, updated_at = NOW() WHERE id = $1`
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("update collection: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete removes the collection and, through the foreign keys, its
+// sub-collections and all their memberships. Items themselves are kept.
+func (cr *CollectionRepository) Delete(ctx context.Context, id string, userID uuid.UUID) error {
+	deleted, err := cr.db.Exec(ctx, `DELETE FROM collections WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete collection: %w", err)
+	}
+	if deleted.RowsAffected() == 0 {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+// Items returns a page of the collection's items that userID can still see,
+// most recently added first, together with the total count.
+func (cr *CollectionRepository) Items(ctx context.Context, id string, userID uuid.UUID, limit, offset int) ([]model.Item, int, error) {
+	var exists bool
+	err := cr.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM collections WHERE id = $1 AND user_id = $2)`, id, userID).Scan(&exists)
+	if err != nil {
+		return nil, 0, fmt.Errorf("collection items: %w", err)
+	}
+	if !exists {
+		return nil, 0, ErrCollectionNotFound
+	}
+
+	sql := `
+		SELECT ` + itemColumns + `, COUNT(*) OVER ()
+		FROM collection_items ci
+		JOIN items ON items.id = ci.item_id
+		WHERE ci.collection_id = $1 AND ` + visibleItem("$2") + `
+		ORDER BY ci.added_at DESC, items.id
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := cr.db.Query(ctx, sql, id, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("collection items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []model.Item{}
+	total := 0
+	for rows.Next() {
+		item, err := scanItem(rows, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("collection items: %w", err)
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("collection items: %w", err)
+	}
+
+	// past the last page the window function has no row to report the total on
+	if len(items) == 0 && offset > 0 {
+		sql = `
+			SELECT COUNT(*)
+			FROM collection_items ci
+			JOIN items ON items.id = ci.item_id
+			WHERE ci.collection_id = $1 AND ` + visibleItem("$2")
+		if err := cr.db.QueryRow(ctx, sql, id, userID).Scan(&total); err != nil {
+			return nil, 0, fmt.Errorf("collection items: %w", err)
+		}
+	}
+	return items, total, nil
+}
+
+// AddItems places the items in the collection; items already in it are left
+// alone. Every item must be visible to userID.
+func (cr *CollectionRepository) AddItems(ctx context.Context, id uuid.UUID, userID uuid.UUID, itemIDs []uuid.UUID) error {
+	tx, err := cr.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("add collection items: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCollection(ctx, tx, id, userID); err != nil {
+		return err
+	}
+	if err := checkVisible(ctx, tx, itemIDs, userID); err != nil {
+		return err
+	}
+	if err := insertCollectionItems(ctx, tx, id, itemIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (cr *CollectionRepository) RemoveItem(ctx context.Context, id uuid.UUID, userID uuid.UUID, itemID uuid.UUID) error {
+	tx, err := cr.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("remove collection item: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCollection(ctx, tx, id, userID); err != nil {
+		return err
+	}
+	if err := deleteCollectionItems(ctx, tx, id, []uuid.UUID{itemID}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// MoveItems takes the items out of one collection and puts them into another
+// in a single transaction. Every item must currently be in the source collection.
+func (cr *CollectionRepository) MoveItems(ctx context.Context, from, to uuid.UUID, userID uuid.UUID, itemIDs []uuid.UUID) error {
+	tx, err := cr.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("move collection items: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCollection(ctx, tx, from, userID); err != nil {
+		return err
+	}
+	if err := lockCollection(ctx, tx, to, userID); err != nil {
+		return err
+	}
+	if err := deleteCollectionItems(ctx, tx, from, itemIDs); err != nil {
+		return err
+	}
+	if err := insertCollectionItems(ctx, tx, to, itemIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func insertCollectionItems(ctx context.Context, tx pgx.Tx, id uuid.UUID, itemIDs []uuid.UUID) error {
+	sql := `
+		INSERT INTO collection_items (collection_id, item_id)
+		SELECT $1, item_id FROM unnest($2::uuid[]) AS item_id
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, sql, id, itemIDs); err != nil {
+		return fmt.Errorf("add collection items: %w", err)
+	}
+	return touchCollection(ctx, tx, id)
+}
+
+// deleteCollectionItems removes the items from the collection, failing with
+// ErrNotInCollection unless all of them were in it.
+func deleteCollectionItems(ctx context.Context, tx pgx.Tx, id uuid.UUID, itemIDs []uuid.UUID) error {
+	deleted, err := tx.Exec(ctx, `DELETE FROM collection_items WHERE collection_id = $1 AND item_id = ANY($2)`, id, itemIDs)
+	if err != nil {
+		return fmt.Errorf("remove collection items: %w", err)
+	}
+	if int(deleted.RowsAffected()) != len(uniqueIDs(itemIDs)) {
+		return ErrNotInCollection
+	}
+	return touchCollection(ctx, tx, id)
+}
+
+func lockCollection(ctx context.Context, tx pgx.Tx, id uuid.UUID, userID uuid.UUID) error {
+	var found uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT id FROM collections WHERE id = $1 AND user_id = $2 FOR UPDATE`, id, userID).Scan(&found)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCollectionNotFound
+		}
+		return fmt.Errorf("lock collection: %w", err)
+	}
+	return nil
+}
+
+func touchCollection(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `UPDATE collections SET updated_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("touch collection: %w", err)
+	}
+	return nil
+}
+
+// checkNoCycle fails when parentID is id or lies below it.
+func checkNoCycle(ctx context.Context, tx pgx.Tx, id, parentID uuid.UUID) error {
+	sql := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM collections WHERE id = $2
+			UNION
+			SELECT c.id, c.parent_id FROM collections c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $1)
+	`
+	var cycle bool
+	if err := tx.QueryRow(ctx, sql, id, parentID).Scan(&cycle); err != nil {
+		return fmt.Errorf("check collection cycle: %w", err)
+	}
+	if cycle {
+		return ErrCollectionCycle
+	}
+	return nil
+}
+
+// checkVisible fails with ErrItemNotFound unless userID can see every item.
+func checkVisible(ctx context.Context, tx pgx.Tx, itemIDs []uuid.UUID, userID uuid.UUID) error {
+	sql := `SELECT COUNT(*) FROM items WHERE items.id = ANY($1) AND ` + visibleItem("$2")
+
+	var visible int
+	if err := tx.QueryRow(ctx, sql, itemIDs, userID).Scan(&visible); err != nil {
+		return fmt.Errorf("check items: %w", err)
+	}
+	if visible != len(uniqueIDs(itemIDs)) {
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+func uniqueIDs(ids []uuid.UUID) map[uuid.UUID]bool {
+	unique := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	return unique
+}
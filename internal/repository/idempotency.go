@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve claims the key for a new request. It returns nil when the claim
+// succeeded, either because the key is new or because its previous use has
+// expired; otherwise it returns the live record already holding the key.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	sql := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, method, path, fingerprint, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET method = EXCLUDED.method,
+		    path = EXCLUDED.path,
+		    fingerprint = EXCLUDED.fingerprint,
+		    status_code = NULL,
+		    response_headers = '{}',
+		    response_body = NULL,
+		    created_at = NOW(),
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING idempotency_key
+	`
+
+	var key string
+	err := r.db.QueryRow(ctx, sql,
+		record.UserID,
+		record.Key,
+		record.Method,
+		record.Path,
+		record.Fingerprint,
+		record.ExpiresAt,
+	).Scan(&key)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+
+	existing, err := r.Get(ctx, record.UserID, record.Key)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		// the holder released the key in the meantime
+		return r.Reserve(ctx, record)
+	}
+	return existing, nil
+}
+
+// Get returns the unexpired record for the key, or nil.
+func (r *IdempotencyRepository) Get(ctx context.Context, userID uuid.UUID, key string) (*model.IdempotencyRecord, error) {
+	sql := `
+		SELECT user_id, idempotency_key, method, path, fingerprint, status_code, response_headers, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2 AND expires_at > NOW()
+	`
+
+	var record model.IdempotencyRecord
+	err := r.db.QueryRow(ctx, sql, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.Method,
+		&record.Path,
+		&record.Fingerprint,
+		&record.StatusCode,
+		&record.Headers,
+		&record.Body,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get idempotency key: %w", err)
+	}
+	return &record, nil
+}
+
+// Complete stores the response of the request holding the key.
+func (r *IdempotencyRepository) Complete(ctx context.Context, userID uuid.UUID, key string, status int, headers map[string][]string, body []byte) error {
+	sql := `
+		UPDATE idempotency_keys
+		SET status_code = $3, response_headers = $4, response_body = $5
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+	if _, err := r.db.Exec(ctx, sql, userID, key, status, headers, body); err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release forgets an unfinished key so the request can be retried.
+func (r *IdempotencyRepository) Release(ctx context.Context, userID uuid.UUID, key string) error {
+	sql := `DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND status_code IS NULL`
+	if _, err := r.db.Exec(ctx, sql, userID, key); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes keys whose window has passed and returns how many went.
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrImageVariantNotFound = errors.New("image variant not found")
+
+const imageVariantColumns = `file_path, name, file_name, width, height, size, mime_type, checksum, created_at`
+
+func scanImageVariant(row pgx.Row) (*model.ImageVariant, error) {
+	var variant model.ImageVariant
+	err := row.Scan(
+		&variant.FilePath,
+		&variant.Name,
+		&variant.FileName,
+		&variant.Width,
+		&variant.Height,
+		&variant.Size,
+		&variant.MimeType,
+		&variant.Checksum,
+		&variant.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// SaveImageVariant records a rendered variant, replacing an older rendering of
+// the same one.
+func (ir *ItemRepository) SaveImageVariant(ctx context.Context, variant *model.ImageVariant) error {
+	sql := `
+		INSERT INTO image_variants (file_path, name, file_name, width, height, size, mime_type, checksum)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (file_path, name) DO UPDATE
+		SET file_name = EXCLUDED.file_name,
+		    width = EXCLUDED.width,
+		    height = EXCLUDED.height,
+		    size = EXCLUDED.size,
+		    mime_type = EXCLUDED.mime_type,
+		    checksum = EXCLUDED.checksum,
+		    created_at = NOW()
+		RETURNING created_at
+	`
+	err := ir.db.QueryRow(ctx, sql,
+		variant.FilePath,
+		variant.Name,
+		variant.FileName,
+		variant.Width,
+		variant.Height,
+		variant.Size,
+		variant.MimeType,
+		variant.Checksum,
+	).Scan(&variant.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("save image variant: %w", err)
+	}
+	return nil
+}
+
+func (ir *ItemRepository) GetImageVariant(ctx context.Context, filePath, name string) (*model.ImageVariant, error) {
+	sql := `SELECT ` + imageVariantColumns + ` FROM image_variants WHERE file_path = $1 AND name = $2`
+
+	variant, err := scanImageVariant(ir.db.QueryRow(ctx, sql, filePath, name))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrImageVariantNotFound
+		}
+		return nil, fmt.Errorf("get image variant: %w", err)
+	}
+	return variant, nil
+}
+
+// GetImageVariants returns the variants of each of the files, keyed by file.
+func (ir *ItemRepository) GetImageVariants(ctx context.Context, filePaths []string) (map[string][]model.ImageVariant, error) {
+	variants := make(map[string][]model.ImageVariant)
+	if len(filePaths) == 0 {
+		return variants, nil
+	}
+
+	sql := `SELECT ` + imageVariantColumns + ` FROM image_variants WHERE file_path = ANY($1) ORDER BY file_path, name`
+	rows, err := ir.db.Query(ctx, sql, filePaths)
+	if err != nil {
+		return nil, fmt.Errorf("get image variants: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		variant, err := scanImageVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get image variants: %w", err)
+		}
+		variants[variant.FilePath] = append(variants[variant.FilePath], *variant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get image variants: %w", err)
+	}
+	return variants, nil
+}
+
+// DeleteImageVariants forgets the variants of the file and returns the files
+// they were stored in.
+func (ir *ItemRepository) DeleteImageVariants(ctx context.Context, filePath string) ([]string, error) {
+	rows, err := ir.db.Query(ctx, `DELETE FROM image_variants WHERE file_path = $1 RETURNING file_name`, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("delete image variants: %w", err)
+	}
+	fileNames, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("delete image variants: %w", err)
+	}
+	return fileNames, nil
+}
+
+// ImagesMissingVariants lists the item images lacking any of the named variants.
+func (ir *ItemRepository) ImagesMissingVariants(ctx context.Context, names []string) ([]string, error) {
+	sql := `
+		SELECT DISTINCT items.file_path
+		FROM items
+		WHERE items.file_path IS NOT NULL AND items.file_path <> ''
+		  AND EXISTS (
+		      SELECT 1 FROM unnest($1::text[]) AS wanted(name)
+		      WHERE NOT EXISTS (
+		          SELECT 1 FROM image_variants v
+		          WHERE v.file_path = items.file_path AND v.name = wanted.name
+		      )
+		  )
+	`
+	rows, err := ir.db.Query(ctx, sql, names)
+	if err != nil {
+		return nil, fmt.Errorf("images missing variants: %w", err)
+	}
+	filePaths, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("images missing variants: %w", err)
+	}
+	return filePaths, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrImportJobNotFound = errors.New("import job not found")
+
+type ImportJobRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewImportJobRepository(db *pgxpool.Pool) *ImportJobRepository {
+	return &ImportJobRepository{db: db}
+}
+
+const importJobColumns = `id, user_id, format, status, total_rows, processed_rows, imported_rows, failed_rows, errors, error, created_at, updated_at, finished_at`
+
+func scanImportJob(row pgx.Row) (*model.ImportJob, error) {
+	var job model.ImportJob
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Format,
+		&job.Status,
+		&job.Total,
+		&job.Processed,
+		&job.Imported,
+		&job.Failed,
+		&job.Errors,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (jr *ImportJobRepository) CreateJob(ctx context.Context, job *model.ImportJob) error {
+	sql := `
+		INSERT INTO import_jobs (user_id, format, total_rows, processed_rows, failed_rows, errors)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + importJobColumns
+
+	created, err := scanImportJob(jr.db.QueryRow(ctx, sql,
+		job.UserID,
+		job.Format,
+		job.Total,
+		job.Processed,
+		job.Failed,
+		job.Errors,
+	))
+	if err != nil {
+		return fmt.Errorf("create import job: %w", err)
+	}
+	*job = *created
+	return nil
+}
+
+// GetJob returns the job only if it belongs to userID.
+func (jr *ImportJobRepository) GetJob(ctx context.Context, jobID string, userID uuid.UUID) (*model.ImportJob, error) {
+	sql := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1 AND user_id = $2`
+
+	job, err := scanImportJob(jr.db.QueryRow(ctx, sql, jobID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrImportJobNotFound
+		}
+		return nil, fmt.Errorf("get import job: %w", err)
+	}
+	return job, nil
+}
+
+// UpdateProgress records the counters and errors of a running job.
+func (jr *ImportJobRepository) UpdateProgress(ctx context.Context, job *model.ImportJob) error {
+	sql := `
+		UPDATE import_jobs
+		SET status = $2, processed_rows = $3, imported_rows = $4, failed_rows = $5, errors = $6, error = $7,
+		    updated_at = NOW(),
+		    finished_at = CASE WHEN $2 IN ('succeeded', 'failed') THEN NOW() END
+		WHERE id = $1
+	`
+	_, err := jr.db.Exec(ctx, sql,
+		job.ID,
+		job.Status,
+		job.Processed,
+		job.Imported,
+		job.Failed,
+		job.Errors,
+		job.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("update import job: %w", err)
+	}
+	return nil
+}
+
+// FailInterrupted marks jobs left pending or running by a previous process as failed.
+func (jr *ImportJobRepository) FailInterrupted(ctx context.Context) error {
+	sql := `
+		UPDATE import_jobs
+		SET status = 'failed', error = 'interrupted by server restart', updated_at = NOW(), finished_at = NOW()
+		WHERE status IN ('pending', 'running')
+	`
+	if _, err := jr.db.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("fail interrupted import jobs: %w", err)
+	}
+	return nil
+}
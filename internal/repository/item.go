@@ -6,10 +6,21 @@ import (
 	"fmt"
 	"mastery-project/internal/model"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	ErrItemNotFound    = errors.New("item not found")
+	ErrVersionMismatch = errors.New("item has been modified")
+	ErrForbidden       = errors.New("insufficient permission for this item")
+)
+
+// itemColumns is the column list every item query selects, in scanItem order.
+// The columns are qualified so queries can join tables that share their names.
+const itemColumns = `items.id, items.user_id, items.title, items.description, COALESCE(items.file_path, ''), items.file_checksum, items.file_size, items.visibility, items.metadata, items.version, items.created_at, items.updated_at`
+
 type ItemRepository struct {
 	db *pgxpool.Pool
 }
@@ -18,43 +29,71 @@ func NewItemRepository(db *pgxpool.Pool) *ItemRepository {
 	return &ItemRepository{db: db}
 }
 
-func (ir *ItemRepository) GetItemByID(ctx context.Context, id string) (*model.Item, error) {
+// scanItem scans itemColumns, followed by any extra columns the query selects.
+func scanItem(row pgx.Row, extra ...any) (*model.Item, error) {
 	var item model.Item
-
-	sql := `
-		SELECT id, user_id, title, description, file_path, created_at, updated_at
-		FROM items
-		WHERE id = $1
-	`
-
-	err := ir.db.QueryRow(ctx, sql, id).Scan(
+	dest := []any{
 		&item.ID,
 		&item.UserID,
 		&item.Title,
 		&item.Description,
 		&item.FilePath,
+		&item.FileChecksum,
+		&item.FileSize,
+		&item.Visibility,
+		&item.Metadata,
+		&item.Version,
 		&item.CreatedAt,
 		&item.UpdateAt,
-	)
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	item.ETag = model.ItemETag(item.Version)
+	return &item, nil
+}
 
+func (ir *ItemRepository) GetItemByID(ctx context.Context, id string) (*model.Item, error) {
+	sql := `SELECT ` + itemColumns + ` FROM items WHERE id = $1`
+
+	item, err := scanItem(ir.db.QueryRow(ctx, sql, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.New("item not found")
+			return nil, ErrItemNotFound
 		}
 		return nil, fmt.Errorf("get item by id: %w", err)
 	}
 
-	return &item, nil
+	return item, nil
 }
 
-func (ir *ItemRepository) GetAllItems(ctx context.Context) ([]model.Item, error) {
+// GetAllItems returns the items userID owns or has been granted access to that
+// match query, each with the user's permission on it, whether it is one of their
+// favorites and its position in their list.
+func (ir *ItemRepository) GetAllItems(ctx context.Context, userID uuid.UUID, query model.ItemQuery) ([]model.Item, error) {
+	args := []any{userID}
+	where := metaConditions(query.Meta, &args)
+	if query.Favorite {
+		where += ` AND f.user_id IS NOT NULL`
+	}
+	orderBy := `items.created_at DESC`
+	if query.Sort == model.SortPosition {
+		orderBy = `p.position NULLS LAST, items.created_at DESC`
+	}
+
 	sql := `
-		SELECT id, user_id, title, description, file_path, created_at, updated_at
+		SELECT ` + itemColumns + `, CASE WHEN items.user_id = $1 THEN 'owner' ELSE s.permission END,
+			f.user_id IS NOT NULL, COALESCE(p.position, '')
 		FROM items
-		ORDER BY created_at DESC
+		LEFT JOIN item_shares s ON s.item_id = items.id AND s.user_id = $1
+		LEFT JOIN item_favorites f ON f.item_id = items.id AND f.user_id = $1
+		LEFT JOIN item_positions p ON p.item_id = items.id AND p.user_id = $1
+		WHERE (items.user_id = $1 OR s.user_id IS NOT NULL)` + where + `
+		ORDER BY ` + orderBy + `
 	`
 
-	rows, err := ir.db.Query(ctx, sql)
+	rows, err := ir.db.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -63,53 +102,236 @@ func (ir *ItemRepository) GetAllItems(ctx context.Context) ([]model.Item, error)
 	var items []model.Item
 
 	for rows.Next() {
-		var item model.Item
-		if err := rows.Scan(
-			&item.ID,
-			&item.UserID,
-			&item.Title,
-			&item.Description,
-			&item.FilePath,
-			&item.CreatedAt,
-			&item.UpdateAt,
-		); err != nil {
+		var permission, position string
+		var favorite bool
+		item, err := scanItem(rows, &permission, &favorite, &position)
+		if err != nil {
 			return nil, err
 		}
-		items = append(items, item)
+		item.Permission = permission
+		item.Favorite = favorite
+		item.Position = position
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetPublicItems returns a page of public items, newest first.
+func (ir *ItemRepository) GetPublicItems(ctx context.Context, limit, offset int) ([]model.Item, error) {
+	sql := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE visibility = 'public'
+		ORDER BY created_at DESC, id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := ir.db.Query(ctx, sql, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("get public items: %w", err)
 	}
+	defer rows.Close()
 
-	return items, nil
+	items := []model.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get public items: %w", err)
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
 }
 
-func (ir *ItemRepository) CreateItem(ctx context.Context, item model.Item) error {
-	sql := "INSERT INTO items (user_id,title, description, file_path) VALUES ($1, $2, $3, $4) RETURNING id"
+// StreamUserItems calls fn for each of the user's items, oldest first, straight
+// off the row cursor so nothing is buffered.
+func (ir *ItemRepository) StreamUserItems(ctx context.Context, userID uuid.UUID, fn func(*model.Item) error) error {
+	sql := `SELECT ` + itemColumns + ` FROM items WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := ir.db.Query(ctx, sql, userID)
+	if err != nil {
+		return fmt.Errorf("stream items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return fmt.Errorf("stream items: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
 
-	err := ir.db.QueryRow(ctx, sql, item.UserID, item.Title, item.Description, item.FilePath).Scan(&item.ID)
+// CreateItem saves a new item, provided its owner has room for it and its
+// image under quota.
+func (ir *ItemRepository) CreateItem(ctx context.Context, item *model.Item, quota model.Quota) error {
+	tx, err := ir.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("error creating item: %s", err)
 	}
-	return nil
+	defer tx.Rollback(ctx)
+
+	if err := reserveQuota(ctx, tx, item.UserID, quota, 1, item.FileSize); err != nil {
+		return err
+	}
+
+	if err := createItemTx(ctx, tx, item); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-func (ir *ItemRepository) UpdateItemByID(ctx context.Context, id string, item model.UpdateItem) error {
+// DuplicateItem creates item as a copy of the source item, pointing at the same
+// image and attachment files; uploads are only deleted once nothing references
+// them. The source row is locked so it cannot be deleted, releasing its files,
+// before the copy is committed. The copy's files count against its owner's
+// quota like new uploads.
+func (ir *ItemRepository) DuplicateItem(ctx context.Context, sourceID string, item *model.Item, quota model.Quota) error {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("duplicate item: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	sql := `UPDATE items SET TITLE = $1, description = $2 WHERE id = $3`
+	err = tx.QueryRow(ctx, `SELECT COALESCE(file_path, ''), file_checksum, file_size FROM items WHERE id = $1 FOR SHARE`, sourceID).Scan(&item.FilePath, &item.FileChecksum, &item.FileSize)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrItemNotFound
+		}
+		return fmt.Errorf("duplicate item: %w", err)
+	}
+
+	var attached int64
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(SUM(size), 0) FROM item_attachments WHERE item_id = $1`, sourceID).Scan(&attached); err != nil {
+		return fmt.Errorf("duplicate item: %w", err)
+	}
+	if err := reserveQuota(ctx, tx, item.UserID, quota, 1, item.FileSize+attached); err != nil {
+		return err
+	}
+
+	if err := createItemTx(ctx, tx, item); err != nil {
+		return err
+	}
+
+	sql := `
+		INSERT INTO item_attachments (item_id, position, original_name, file_name, size, mime_type, checksum)
+		SELECT $2, position, original_name, file_name, size, mime_type, checksum
+		FROM item_attachments
+		WHERE item_id = $1
+	`
+	if _, err := tx.Exec(ctx, sql, sourceID, item.ID); err != nil {
+		return fmt.Errorf("duplicate item: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// createItemTx inserts the item with its first revision and puts it at the top
+// of its owner's list.
+func createItemTx(ctx context.Context, tx pgx.Tx, item *model.Item) error {
+	sql := `
+		INSERT INTO items (user_id, title, description, file_path, file_checksum, file_size, visibility, metadata)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, COALESCE(NULLIF($7, ''), 'private'), COALESCE($8::jsonb, '{}'))
+		RETURNING id, visibility, version, created_at, updated_at
+	`
 
-	updates, err := ir.db.Exec(ctx, sql, item.Title, item.Description, id)
+	err := tx.QueryRow(ctx, sql, item.UserID, item.Title, item.Description, item.FilePath, item.FileChecksum, item.FileSize, item.Visibility, metadataArg(item.Metadata)).Scan(
+		&item.ID,
+		&item.Visibility,
+		&item.Version,
+		&item.CreatedAt,
+		&item.UpdateAt,
+	)
 	if err != nil {
-		return fmt.Errorf("error updating item: %s", err)
+		return fmt.Errorf("error creating item: %s", err)
+	}
+	item.ETag = model.ItemETag(item.Version)
+	if item.Metadata == nil {
+		item.Metadata = model.Metadata{}
 	}
-	if updates.RowsAffected() == 0 {
-		return errors.New("item not found")
+
+	// the first revision records the item as it was created
+	if err := insertRevision(ctx, tx, item.ID, item.UserID, item.Title, item.Description, item.Visibility, item.Metadata, []string{"title", "description"}); err != nil {
+		return fmt.Errorf("error creating item: %s", err)
+	}
+	if err := insertPositions(ctx, tx, item.UserID, []uuid.UUID{item.ID}); err != nil {
+		return fmt.Errorf("error creating item: %s", err)
 	}
 	return nil
 }
 
-func (ir *ItemRepository) DeleteItemByID(ctx context.Context, id string) error {
-	sql := `DELETE FROM items WHERE id = $1`
-	_, err := ir.db.Exec(ctx, sql, id)
+// UpdateItemByID writes the fields set in item and records the change as a
+// revision authored by authorID, both in the same transaction. A non-zero
+// expectedVersion makes the write conditional on the row still being at that
+// version. The version the item ends up at is returned.
+func (ir *ItemRepository) UpdateItemByID(ctx context.Context, id string, authorID uuid.UUID, expectedVersion int, item model.UpdateItem) (int, error) {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error updating item: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	version, err := updateItemTx(ctx, tx, id, authorID, expectedVersion, item)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, tx.Commit(ctx)
+}
+
+// DeleteItemByID removes the item, optionally only if it is still at expectedVersion.
+func (ir *ItemRepository) DeleteItemByID(ctx context.Context, id string, expectedVersion int) error {
+	sql := `DELETE FROM items WHERE id = $1 AND ($2 = 0 OR version = $2)`
+	deleted, err := ir.db.Exec(ctx, sql, id, expectedVersion)
 	if err != nil {
 		return fmt.Errorf("error deleting item: %s", err)
 	}
+	if deleted.RowsAffected() == 0 {
+		if _, err := ir.GetItemByID(ctx, id); err != nil {
+			return err
+		}
+		return ErrVersionMismatch
+	}
 	return nil
 }
+
+// SetItemImage points the item at a new image file with the given SHA-256
+// checksum and size, or at none when filePath is empty, and returns the file
+// it referenced before so the caller can release it once the row no longer
+// points at it. A larger image must fit in the owner's quota.
+func (ir *ItemRepository) SetItemImage(ctx context.Context, id string, expectedVersion int, filePath, checksum string, size int64, quota model.Quota) (string, int, error) {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("set item image: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var previous string
+	var version int
+	var owner uuid.UUID
+	var previousSize int64
+	err = tx.QueryRow(ctx, `SELECT COALESCE(file_path, ''), version, user_id, file_size FROM items WHERE id = $1 FOR UPDATE`, id).Scan(&previous, &version, &owner, &previousSize)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", 0, ErrItemNotFound
+		}
+		return "", 0, fmt.Errorf("set item image: %w", err)
+	}
+	if expectedVersion != 0 && version != expectedVersion {
+		return "", 0, ErrVersionMismatch
+	}
+	if err := reserveQuota(ctx, tx, owner, quota, 0, size-previousSize); err != nil {
+		return "", 0, err
+	}
+
+	sql := `UPDATE items SET file_path = NULLIF($2, ''), file_checksum = $3, file_size = $4, version = version + 1, updated_at = NOW() WHERE id = $1 RETURNING version`
+	if err := tx.QueryRow(ctx, sql, id, filePath, checksum, size).Scan(&version); err != nil {
+		return "", 0, fmt.Errorf("set item image: %w", err)
+	}
+
+	return previous, version, tx.Commit(ctx)
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentLimit    = errors.New("attachment limit reached")
+	ErrInvalidOrder       = errors.New("order must list every attachment of the item exactly once")
+)
+
+const attachmentColumns = `id, item_id, position, original_name, file_name, size, mime_type, checksum, created_at`
+
+func scanAttachment(row pgx.Row) (*model.Attachment, error) {
+	var attachment model.Attachment
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.ItemID,
+		&attachment.Position,
+		&attachment.OriginalName,
+		&attachment.FileName,
+		&attachment.Size,
+		&attachment.MimeType,
+		&attachment.Checksum,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (ir *ItemRepository) GetAttachments(ctx context.Context, itemID string) ([]model.Attachment, error) {
+	sql := `SELECT ` + attachmentColumns + ` FROM item_attachments WHERE item_id = $1 ORDER BY position`
+
+	rows, err := ir.db.Query(ctx, sql, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("get attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []model.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get attachments: %w", err)
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, rows.Err()
+}
+
+func (ir *ItemRepository) GetAttachment(ctx context.Context, itemID, attachmentID string) (*model.Attachment, error) {
+	sql := `SELECT ` + attachmentColumns + ` FROM item_attachments WHERE id = $1 AND item_id = $2`
+
+	attachment, err := scanAttachment(ir.db.QueryRow(ctx, sql, attachmentID, itemID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("get attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+// AddAttachment appends the attachment after the item's last one. The item row
+// is locked so concurrent uploads cannot push the item past maxAttachments,
+// and the file must fit in the item owner's quota.
+func (ir *ItemRepository) AddAttachment(ctx context.Context, attachment *model.Attachment, maxAttachments int, quota model.Quota) error {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("add attachment: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockItem(ctx, tx, attachment.ItemID.String()); err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM item_attachments WHERE item_id = $1`, attachment.ItemID).Scan(&count); err != nil {
+		return fmt.Errorf("add attachment: %w", err)
+	}
+	if maxAttachments > 0 && count >= maxAttachments {
+		return ErrAttachmentLimit
+	}
+
+	owner, err := itemOwner(ctx, tx, attachment.ItemID.String())
+	if err != nil {
+		return err
+	}
+	if err := reserveQuota(ctx, tx, owner, quota, 0, attachment.Size); err != nil {
+		return err
+	}
+
+	sql := `
+		INSERT INTO item_attachments (item_id, position, original_name, file_name, size, mime_type, checksum)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + attachmentColumns
+
+	inserted, err := scanAttachment(tx.QueryRow(ctx, sql,
+		attachment.ItemID,
+		count,
+		attachment.OriginalName,
+		attachment.FileName,
+		attachment.Size,
+		attachment.MimeType,
+		attachment.Checksum,
+	))
+	if err != nil {
+		return fmt.Errorf("add attachment: %w", err)
+	}
+	*attachment = *inserted
+
+	if err := touchItem(ctx, tx, attachment.ItemID.String()); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ReorderAttachments assigns positions in the order of ids, which must be a
+// permutation of the item's attachment ids.
+func (ir *ItemRepository) ReorderAttachments(ctx context.Context, itemID string, ids []uuid.UUID) error {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("reorder attachments: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockItem(ctx, tx, itemID); err != nil {
+		return err
+	}
+
+	var matching, total int
+	sql := `
+		SELECT COUNT(*) FILTER (WHERE id = ANY($2)), COUNT(*)
+		FROM item_attachments
+		WHERE item_id = $1
+	`
+	if err := tx.QueryRow(ctx, sql, itemID, ids).Scan(&matching, &total); err != nil {
+		return fmt.Errorf("reorder attachments: %w", err)
+	}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	if len(seen) != len(ids) || matching != len(ids) || total != len(ids) {
+		return ErrInvalidOrder
+	}
+
+	sql = `
+		UPDATE item_attachments a
+		SET position = o.ord - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, ord)
+		WHERE a.id = o.id AND a.item_id = $1
+	`
+	if _, err := tx.Exec(ctx, sql, itemID, ids); err != nil {
+		return fmt.Errorf("reorder attachments: %w", err)
+	}
+
+	if err := touchItem(ctx, tx, itemID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteAttachment removes the attachment, closes the gap it leaves in the
+// positions and returns the removed row so its file can be released.
+func (ir *ItemRepository) DeleteAttachment(ctx context.Context, itemID, attachmentID string) (*model.Attachment, error) {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("delete attachment: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockItem(ctx, tx, itemID); err != nil {
+		return nil, err
+	}
+
+	sql := `DELETE FROM item_attachments WHERE id = $1 AND item_id = $2 RETURNING ` + attachmentColumns
+	deleted, err := scanAttachment(tx.QueryRow(ctx, sql, attachmentID, itemID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("delete attachment: %w", err)
+	}
+
+	sql = `UPDATE item_attachments SET position = position - 1 WHERE item_id = $1 AND position > $2`
+	if _, err := tx.Exec(ctx, sql, itemID, deleted.Position); err != nil {
+		return nil, fmt.Errorf("delete attachment: %w", err)
+	}
+
+	if err := touchItem(ctx, tx, itemID); err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit(ctx)
+}
+
+// LegacyAttachments lists the attachments whose size and checksum were never
+// recorded, such as the item images the attachments migration moved over.
+func (ir *ItemRepository) LegacyAttachments(ctx context.Context) ([]model.Attachment, error) {
+	sql := `SELECT ` + attachmentColumns + ` FROM item_attachments WHERE checksum = '' ORDER BY created_at`
+
+	rows, err := ir.db.Query(ctx, sql)
+	if err != nil {
+		return nil, fmt.Errorf("legacy attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []model.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("legacy attachments: %w", err)
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// SetAttachmentContent records the size and checksum of a legacy attachment and
+// of its file, whose blob row lacks them too. The owner's usage follows the
+// new size through its trigger, and the item's version is bumped since its
+// representation lists both.
+func (ir *ItemRepository) SetAttachmentContent(ctx context.Context, attachment *model.Attachment, checksum string, size int64) error {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("set attachment content: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sql := `UPDATE item_attachments SET size = $2, checksum = $3 WHERE id = $1 AND checksum = ''`
+	tag, err := tx.Exec(ctx, sql, attachment.ID, size, checksum)
+	if err != nil {
+		return fmt.Errorf("set attachment content: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		// deleted or filled in by another instance meanwhile
+		return nil
+	}
+	sql = `UPDATE blobs SET checksum = $2, size = $3, updated_at = NOW() WHERE key = $1 AND checksum = ''`
+	if _, err := tx.Exec(ctx, sql, attachment.FileName, checksum, size); err != nil {
+		return fmt.Errorf("set attachment content: %w", err)
+	}
+	if err := touchItem(ctx, tx, attachment.ItemID.String()); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// FileInUse reports whether an item image or attachment still references the file.
+func (ir *ItemRepository) FileInUse(ctx context.Context, fileName string) (bool, error) {
+	sql := `
+		SELECT EXISTS (SELECT 1 FROM items WHERE file_path = $1)
+		    OR EXISTS (SELECT 1 FROM item_attachments WHERE file_name = $1)
+	`
+	var inUse bool
+	if err := ir.db.QueryRow(ctx, sql, fileName).Scan(&inUse); err != nil {
+		return false, fmt.Errorf("file in use: %w", err)
+	}
+	return inUse, nil
+}
+
+func lockItem(ctx context.Context, tx pgx.Tx, itemID string) error {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT id FROM items WHERE id = $1 FOR UPDATE`, itemID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrItemNotFound
+		}
+		return fmt.Errorf("lock item: %w", err)
+	}
+	return nil
+}
+
+// touchItem bumps version and updated_at after a change to data the item
+// representation includes, so cached ETags stop matching.
+func touchItem(ctx context.Context, tx pgx.Tx, itemID string) error {
+	if _, err := tx.Exec(ctx, `UPDATE items SET version = version + 1, updated_at = NOW() WHERE id = $1`, itemID); err != nil {
+		return fmt.Errorf("touch item: %w", err)
+	}
+	return nil
+}
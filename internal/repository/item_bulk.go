@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// BulkOpResult is the outcome of one bulk operation; Err is nil on success.
+type BulkOpResult struct {
+	Index   int
+	Op      string
+	ID      *uuid.UUID
+	Version int
+	Err     error
+}
+
+// BulkOutcome lists what RunBulk did, plus the files deleted items no longer
+// reference so the caller can release them after the commit.
+type BulkOutcome struct {
+	Results  []BulkOpResult
+	Released []string
+}
+
+// RunBulk executes the operations for userID inside a single transaction.
+// Creates are loaded first with CopyFrom, then updates and deletes run in
+// request order, each inside its own savepoint. In atomic mode the first failing
+// operation rolls everything back and is returned as the error together with the
+// results gathered so far; otherwise failures are recorded per operation and the
+// rest is committed. Updates need at least editor access to the item and deletes
+// need ownership. Creates that would take userID past the item quota fail: in
+// atomic mode all of them, otherwise only those beyond the remaining allowance,
+// in request order.
+func (ir *ItemRepository) RunBulk(ctx context.Context, userID uuid.UUID, ops []model.BulkOperation, atomic bool, quota model.Quota) (*BulkOutcome, error) {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("bulk: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	outcome := &BulkOutcome{}
+
+	var creates []model.BulkOperation
+	for _, op := range ops {
+		if op.Op == "create" {
+			creates = append(creates, op)
+		}
+	}
+	if len(creates) > 0 {
+		var err error
+		if atomic {
+			err = reserveQuota(ctx, tx, userID, quota, len(creates), 0)
+			if err != nil {
+				for _, op := range creates {
+					outcome.Results = append(outcome.Results, BulkOpResult{Index: op.Index, Op: op.Op, Err: err})
+				}
+				return outcome, err
+			}
+		} else {
+			var over []BulkOpResult
+			creates, over, err = trimToQuota(ctx, tx, userID, quota, creates)
+			if err != nil {
+				return outcome, err
+			}
+			outcome.Results = append(outcome.Results, over...)
+		}
+	}
+	if len(creates) > 0 {
+		results, err := bulkCreate(ctx, tx, userID, creates, atomic)
+		outcome.Results = append(outcome.Results, results...)
+		if err != nil {
+			return outcome, err
+		}
+	}
+
+	for _, op := range ops {
+		if op.Op == "create" {
+			continue
+		}
+		result := BulkOpResult{Index: op.Index, Op: op.Op, ID: op.ID}
+
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return outcome, fmt.Errorf("bulk: %w", err)
+		}
+
+		switch op.Op {
+		case "update":
+			result.Version, result.Err = bulkUpdate(ctx, sp, userID, op)
+		case "delete":
+			var released []string
+			released, result.Err = bulkDelete(ctx, sp, userID, op)
+			if result.Err == nil {
+				outcome.Released = append(outcome.Released, released...)
+			}
+		}
+
+		if result.Err == nil {
+			result.Err = sp.Commit(ctx)
+		} else {
+			_ = sp.Rollback(ctx)
+		}
+		outcome.Results = append(outcome.Results, result)
+
+		if result.Err != nil && atomic {
+			outcome.Released = nil
+			return outcome, result.Err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return outcome, fmt.Errorf("bulk: %w", err)
+	}
+	return outcome, nil
+}
+
+// trimToQuota keeps the creates that fit in userID's remaining item allowance
+// and fails the rest.
+func trimToQuota(ctx context.Context, tx pgx.Tx, userID uuid.UUID, quota model.Quota, creates []model.BulkOperation) ([]model.BulkOperation, []BulkOpResult, error) {
+	usage, err := lockUsage(ctx, tx, userID, quota)
+	if err != nil {
+		return nil, nil, err
+	}
+	room := len(creates)
+	if usage.Quota.MaxItems > 0 {
+		room = min(room, max(usage.Quota.MaxItems-usage.Items, 0))
+	}
+
+	var over []BulkOpResult
+	for _, op := range creates[room:] {
+		err := fmt.Errorf("%w: %d of %d items used", ErrItemQuota, usage.Items, usage.Quota.MaxItems)
+		over = append(over, BulkOpResult{Index: op.Index, Op: op.Op, Err: err})
+	}
+	return creates[:room], over, nil
+}
+
+// bulkCreate copies all new items and their first revisions in with CopyFrom.
+// If that fails in best-effort mode, it falls back to inserting row by row so
+// one bad row only fails its own operation.
+func bulkCreate(ctx context.Context, tx pgx.Tx, userID uuid.UUID, creates []model.BulkOperation, atomic bool) ([]BulkOpResult, error) {
+	ids := make([]uuid.UUID, len(creates))
+	for i := range creates {
+		ids[i] = uuid.New()
+	}
+
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("bulk create: %w", err)
+	}
+
+	_, err = sp.CopyFrom(ctx,
+		pgx.Identifier{"items"},
+		[]string{"id", "user_id", "title", "description"},
+		pgx.CopyFromSlice(len(creates), func(i int) ([]any, error) {
+			return []any{ids[i], userID, *creates[i].Title, *creates[i].Description}, nil
+		}),
+	)
+	if err == nil {
+		_, err = sp.CopyFrom(ctx,
+			pgx.Identifier{"item_revisions"},
+			[]string{"item_id", "revision", "author_id", "title", "description", "visibility", "metadata", "changed_fields"},
+			pgx.CopyFromSlice(len(creates), func(i int) ([]any, error) {
+				return []any{ids[i], 1, userID, *creates[i].Title, *creates[i].Description, model.VisibilityPrivate, model.Metadata{}, []string{"title", "description"}}, nil
+			}),
+		)
+	}
+	if err == nil {
+		err = insertPositions(ctx, sp, userID, ids)
+	}
+	if err == nil {
+		err = sp.Commit(ctx)
+	}
+
+	if err == nil {
+		results := make([]BulkOpResult, len(creates))
+		for i, op := range creates {
+			results[i] = BulkOpResult{Index: op.Index, Op: op.Op, ID: &ids[i], Version: 1}
+		}
+		return results, nil
+	}
+	_ = sp.Rollback(ctx)
+
+	if atomic {
+		results := make([]BulkOpResult, len(creates))
+		for i, op := range creates {
+			results[i] = BulkOpResult{Index: op.Index, Op: op.Op, Err: fmt.Errorf("error creating item: %s", err)}
+		}
+		return results, fmt.Errorf("error creating item: %s", err)
+	}
+
+	results := make([]BulkOpResult, 0, len(creates))
+	var created []uuid.UUID
+	for i, op := range creates {
+		result := BulkOpResult{Index: op.Index, Op: op.Op}
+		if result.Err = insertOne(ctx, tx, ids[i], userID, op); result.Err == nil {
+			result.ID = &ids[i]
+			result.Version = 1
+			created = append(created, ids[i])
+		}
+		results = append(results, result)
+	}
+	if err := insertPositions(ctx, tx, userID, created); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// insertOne inserts a single item and its first revision as one pgx.Batch inside a savepoint.
+func insertOne(ctx context.Context, tx pgx.Tx, id, userID uuid.UUID, op model.BulkOperation) error {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating item: %s", err)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`INSERT INTO items (id, user_id, title, description) VALUES ($1, $2, $3, $4)`,
+		id, userID, *op.Title, *op.Description)
+	batch.Queue(`INSERT INTO item_revisions (item_id, revision, author_id, title, description, visibility, metadata, changed_fields) VALUES ($1, 1, $2, $3, $4, 'private', '{}', $5)`,
+		id, userID, *op.Title, *op.Description, []string{"title", "description"})
+
+	if err := sp.SendBatch(ctx, batch).Close(); err != nil {
+		_ = sp.Rollback(ctx)
+		return fmt.Errorf("error creating item: %s", err)
+	}
+	return sp.Commit(ctx)
+}
+
+func bulkUpdate(ctx context.Context, tx pgx.Tx, userID uuid.UUID, op model.BulkOperation) (int, error) {
+	if err := checkPermission(ctx, tx, op.ID.String(), userID, model.PermissionEditor); err != nil {
+		return 0, err
+	}
+	return updateItemTx(ctx, tx, op.ID.String(), userID, op.Version, model.UpdateItem{
+		Title:       op.Title,
+		Description: op.Description,
+	})
+}
+
+func bulkDelete(ctx context.Context, tx pgx.Tx, userID uuid.UUID, op model.BulkOperation) ([]string, error) {
+	if err := checkPermission(ctx, tx, op.ID.String(), userID, model.PermissionOwner); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `SELECT file_name FROM item_attachments WHERE item_id = $1`, op.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting item: %s", err)
+	}
+	files, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("error deleting item: %s", err)
+	}
+
+	var filePath string
+	sql := `DELETE FROM items WHERE id = $1 AND ($2 = 0 OR version = $2) RETURNING COALESCE(file_path, '')`
+	if err := tx.QueryRow(ctx, sql, op.ID, op.Version).Scan(&filePath); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrVersionMismatch
+		}
+		return nil, fmt.Errorf("error deleting item: %s", err)
+	}
+	if filePath != "" {
+		files = append(files, filePath)
+	}
+	return files, nil
+}
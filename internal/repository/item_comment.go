@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrCommentNotFound      = errors.New("comment not found")
+	ErrInvalidParentComment = errors.New("replies can only be made to top-level comments of the same item")
+)
+
+const commentColumns = `c.id, c.item_id, c.user_id, COALESCE(u.name, ''), c.parent_id, c.body, c.created_at, c.updated_at`
+
+func scanComment(row pgx.Row, extra ...any) (*model.Comment, error) {
+	var comment model.Comment
+	dest := []any{
+		&comment.ID,
+		&comment.ItemID,
+		&comment.UserID,
+		&comment.AuthorName,
+		&comment.ParentID,
+		&comment.Body,
+		&comment.CreatedAt,
+		&comment.UpdateAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	comment.Mentions = []model.Mention{}
+	return &comment, nil
+}
+
+// GetComments returns a page of the item's top-level comments, oldest first,
+// each with all of its replies, plus the number of top-level comments.
+func (ir *ItemRepository) GetComments(ctx context.Context, itemID string, limit, offset int) ([]model.Comment, int, error) {
+	sql := `
+		SELECT ` + commentColumns + `, COUNT(*) OVER ()
+		FROM item_comments c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.item_id = $1 AND c.parent_id IS NULL
+		ORDER BY c.created_at, c.id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := ir.db.Query(ctx, sql, itemID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get comments: %w", err)
+	}
+	comments := []model.Comment{}
+	total := 0
+	for rows.Next() {
+		comment, err := scanComment(rows, &total)
+		if err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("get comments: %w", err)
+		}
+		comments = append(comments, *comment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("get comments: %w", err)
+	}
+
+	if len(comments) == 0 {
+		if offset > 0 {
+			sql = `SELECT COUNT(*) FROM item_comments WHERE item_id = $1 AND parent_id IS NULL`
+			if err := ir.db.QueryRow(ctx, sql, itemID).Scan(&total); err != nil {
+				return nil, 0, fmt.Errorf("get comments: %w", err)
+			}
+		}
+		return comments, total, nil
+	}
+
+	parents := make([]uuid.UUID, len(comments))
+	byID := make(map[uuid.UUID]*model.Comment, len(comments))
+	for i := range comments {
+		parents[i] = comments[i].ID
+		byID[comments[i].ID] = &comments[i]
+	}
+
+	sql = `
+		SELECT ` + commentColumns + `
+		FROM item_comments c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.parent_id = ANY($1)
+		ORDER BY c.created_at, c.id
+	`
+	rows, err = ir.db.Query(ctx, sql, parents)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get comments: %w", err)
+	}
+	var replies []model.Comment
+	for rows.Next() {
+		reply, err := scanComment(rows)
+		if err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("get comments: %w", err)
+		}
+		replies = append(replies, *reply)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("get comments: %w", err)
+	}
+
+	all := make([]*model.Comment, 0, len(comments)+len(replies))
+	for i := range comments {
+		all = append(all, &comments[i])
+	}
+	for i := range replies {
+		all = append(all, &replies[i])
+	}
+	if err := ir.loadMentions(ctx, all); err != nil {
+		return nil, 0, err
+	}
+
+	for _, reply := range replies {
+		parent := byID[*reply.ParentID]
+		parent.Replies = append(parent.Replies, reply)
+	}
+	return comments, total, nil
+}
+
+func (ir *ItemRepository) GetComment(ctx context.Context, itemID, commentID string) (*model.Comment, error) {
+	sql := `
+		SELECT ` + commentColumns + `
+		FROM item_comments c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1 AND c.item_id = $2
+	`
+	comment, err := scanComment(ir.db.QueryRow(ctx, sql, commentID, itemID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("get comment: %w", err)
+	}
+	if err := ir.loadMentions(ctx, []*model.Comment{comment}); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// CreateComment stores the comment and records mentions of the users named in
+// mentions who can see the item.
+func (ir *ItemRepository) CreateComment(ctx context.Context, comment *model.Comment, mentions []string) error {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create comment: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if comment.ParentID != nil {
+		var topLevel bool
+		sql := `SELECT parent_id IS NULL FROM item_comments WHERE id = $1 AND item_id = $2`
+		if err := tx.QueryRow(ctx, sql, comment.ParentID, comment.ItemID).Scan(&topLevel); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrInvalidParentComment
+			}
+			return fmt.Errorf("create comment: %w", err)
+		}
+		if !topLevel {
+			return ErrInvalidParentComment
+		}
+	}
+
+	sql := `
+		INSERT INTO item_comments (item_id, user_id, parent_id, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, sql, comment.ItemID, comment.UserID, comment.ParentID, comment.Body).Scan(
+		&comment.ID,
+		&comment.CreatedAt,
+		&comment.UpdateAt,
+	)
+	if err != nil {
+		return fmt.Errorf("create comment: %w", err)
+	}
+
+	if err := insertMentions(ctx, tx, comment.ID, comment.ItemID, mentions); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UpdateComment replaces the comment's body and its mentions.
+func (ir *ItemRepository) UpdateComment(ctx context.Context, comment *model.Comment, mentions []string) error {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("update comment: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sql := `UPDATE item_comments SET body = $2, updated_at = NOW() WHERE id = $1 RETURNING updated_at`
+	if err := tx.QueryRow(ctx, sql, comment.ID, comment.Body).Scan(&comment.UpdateAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCommentNotFound
+		}
+		return fmt.Errorf("update comment: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM comment_mentions WHERE comment_id = $1`, comment.ID); err != nil {
+		return fmt.Errorf("update comment: %w", err)
+	}
+	if err := insertMentions(ctx, tx, comment.ID, comment.ItemID, mentions); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteComment removes the comment together with its replies.
+func (ir *ItemRepository) DeleteComment(ctx context.Context, itemID, commentID string) error {
+	deleted, err := ir.db.Exec(ctx, `DELETE FROM item_comments WHERE id = $1 AND item_id = $2`, commentID, itemID)
+	if err != nil {
+		return fmt.Errorf("delete comment: %w", err)
+	}
+	if deleted.RowsAffected() == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
+}
+
+// insertMentions links the comment to the users whose name matches one of
+// names, case-insensitively, as long as they can see the item.
+func insertMentions(ctx context.Context, tx pgx.Tx, commentID, itemID uuid.UUID, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	sql := `
+		INSERT INTO comment_mentions (comment_id, user_id)
+		SELECT $1, u.id
+		FROM users u
+		JOIN items ON items.id = $2
+		WHERE lower(u.name) = ANY($3) AND ` + visibleItem("u.id") + `
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, sql, commentID, itemID, names); err != nil {
+		return fmt.Errorf("insert mentions: %w", err)
+	}
+	return nil
+}
+
+func (ir *ItemRepository) loadMentions(ctx context.Context, comments []*model.Comment) error {
+	ids := make([]uuid.UUID, len(comments))
+	byID := make(map[uuid.UUID]*model.Comment, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+		byID[comment.ID] = comment
+	}
+
+	sql := `
+		SELECT m.comment_id, u.id, u.name
+		FROM comment_mentions m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.comment_id = ANY($1)
+		ORDER BY u.name
+	`
+	rows, err := ir.db.Query(ctx, sql, ids)
+	if err != nil {
+		return fmt.Errorf("load mentions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentID uuid.UUID
+		var mention model.Mention
+		if err := rows.Scan(&commentID, &mention.UserID, &mention.Name); err != nil {
+			return fmt.Errorf("load mentions: %w", err)
+		}
+		comment := byID[commentID]
+		comment.Mentions = append(comment.Mentions, mention)
+	}
+	return rows.Err()
+}
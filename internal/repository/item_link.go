@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrLinkNotFound = errors.New("link not found")
+	ErrLinkGone     = errors.New("link has expired or been revoked")
+)
+
+const linkColumns = `id, item_id, created_by, COALESCE(password_hash, ''), expires_at, max_views, view_count, revoked_at, last_viewed_at, created_at`
+
+func scanLink(row pgx.Row) (*model.ItemLink, error) {
+	var link model.ItemLink
+	err := row.Scan(
+		&link.ID,
+		&link.ItemID,
+		&link.CreatedBy,
+		&link.PasswordHash,
+		&link.ExpiresAt,
+		&link.MaxViews,
+		&link.ViewCount,
+		&link.RevokedAt,
+		&link.LastViewedAt,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	link.HasPassword = link.PasswordHash != ""
+	return &link, nil
+}
+
+// CreateLink stores the link under the hash of its token.
+func (ir *ItemRepository) CreateLink(ctx context.Context, link *model.ItemLink, tokenHash string) error {
+	sql := `
+		INSERT INTO item_links (item_id, created_by, token_hash, password_hash, expires_at, max_views)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING ` + linkColumns
+
+	created, err := scanLink(ir.db.QueryRow(ctx, sql,
+		link.ItemID,
+		link.CreatedBy,
+		tokenHash,
+		link.PasswordHash,
+		link.ExpiresAt,
+		link.MaxViews,
+	))
+	if err != nil {
+		return fmt.Errorf("create link: %w", err)
+	}
+	created.Token = link.Token
+	*link = *created
+	return nil
+}
+
+func (ir *ItemRepository) GetLinks(ctx context.Context, itemID string) ([]model.ItemLink, error) {
+	sql := `SELECT ` + linkColumns + ` FROM item_links WHERE item_id = $1 ORDER BY created_at DESC`
+
+	rows, err := ir.db.Query(ctx, sql, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("get links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []model.ItemLink{}
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get links: %w", err)
+		}
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+func (ir *ItemRepository) GetLinkByToken(ctx context.Context, tokenHash string) (*model.ItemLink, error) {
+	sql := `SELECT ` + linkColumns + ` FROM item_links WHERE token_hash = $1`
+
+	link, err := scanLink(ir.db.QueryRow(ctx, sql, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("get link: %w", err)
+	}
+	return link, nil
+}
+
+// RevokeLink disables the link. Revoking an already revoked link is a no-op.
+func (ir *ItemRepository) RevokeLink(ctx context.Context, itemID, linkID string) (*model.ItemLink, error) {
+	sql := `
+		UPDATE item_links SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND item_id = $2
+		RETURNING ` + linkColumns
+
+	link, err := scanLink(ir.db.QueryRow(ctx, sql, linkID, itemID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("revoke link: %w", err)
+	}
+	return link, nil
+}
+
+// CountLinkView records one view of the link. The checks and the increment are
+// a single statement, so concurrent views can never exceed max_views.
+func (ir *ItemRepository) CountLinkView(ctx context.Context, linkID string) error {
+	sql := `
+		UPDATE item_links
+		SET view_count = view_count + 1, last_viewed_at = NOW()
+		WHERE id = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		  AND (max_views IS NULL OR view_count < max_views)
+	`
+	counted, err := ir.db.Exec(ctx, sql, linkID)
+	if err != nil {
+		return fmt.Errorf("count link view: %w", err)
+	}
+	if counted.RowsAffected() == 0 {
+		return ErrLinkGone
+	}
+	return nil
+}
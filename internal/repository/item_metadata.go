@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrMetadataSchemaNotFound = errors.New("no metadata schema configured")
+
+// metaRangeOperators maps the range operators accepted in metadata filters to SQL.
+var metaRangeOperators = map[string]string{
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// metadataArg turns metadata into a query argument; nil becomes SQL NULL rather
+// than a JSON null.
+func metadataArg(metadata model.Metadata) any {
+	if metadata == nil {
+		return nil
+	}
+	return metadata
+}
+
+// metaConditions renders filters as " AND ..." conditions on items.metadata,
+// appending their arguments to args. Equality filters use containment so the
+// GIN index applies; the value matches as a string, or as a number or boolean
+// when it reads as one. Range filters compare numerically and only match
+// numbers.
+func metaConditions(filters []model.MetaFilter, args *[]any) string {
+	var where strings.Builder
+	for _, filter := range filters {
+		if op, ok := metaRangeOperators[filter.Op]; ok {
+			*args = append(*args, filter.Path[0], filter.Path, filter.Value)
+			key, path, value := len(*args)-2, len(*args)-1, len(*args)
+			fmt.Fprintf(&where, ` AND items.metadata ? $%d AND CASE WHEN jsonb_typeof(items.metadata #> $%d) = 'number' THEN (items.metadata #>> $%d)::numeric END %s $%d::text::numeric`,
+				key, path, path, op, value)
+			continue
+		}
+
+		var alternatives []string
+		for _, value := range metaValues(filter.Value) {
+			doc := value
+			for i := len(filter.Path) - 1; i >= 0; i-- {
+				doc = map[string]any{filter.Path[i]: doc}
+			}
+			encoded, err := json.Marshal(doc)
+			if err != nil {
+				continue
+			}
+			*args = append(*args, string(encoded))
+			alternatives = append(alternatives, fmt.Sprintf("items.metadata @> $%d::jsonb", len(*args)))
+		}
+		where.WriteString(" AND (" + strings.Join(alternatives, " OR ") + ")")
+	}
+	return where.String()
+}
+
+// metaValues lists the JSON values a filter's query string value may stand for.
+func metaValues(raw string) []any {
+	values := []any{raw}
+	switch raw {
+	case "true":
+		values = append(values, true)
+	case "false":
+		values = append(values, false)
+	default:
+		if raw != "" && (raw[0] == '-' || (raw[0] >= '0' && raw[0] <= '9')) && json.Valid([]byte(raw)) {
+			values = append(values, json.Number(raw))
+		}
+	}
+	return values
+}
+
+// GetMetadataSchema returns the JSON Schema userID's item metadata must satisfy.
+func (ir *ItemRepository) GetMetadataSchema(ctx context.Context, userID uuid.UUID) (json.RawMessage, error) {
+	var schema json.RawMessage
+	err := ir.db.QueryRow(ctx, `SELECT schema FROM user_metadata_schemas WHERE user_id = $1`, userID).Scan(&schema)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMetadataSchemaNotFound
+		}
+		return nil, fmt.Errorf("get metadata schema: %w", err)
+	}
+	return schema, nil
+}
+
+func (ir *ItemRepository) SetMetadataSchema(ctx context.Context, userID uuid.UUID, schema json.RawMessage) error {
+	sql := `
+		INSERT INTO user_metadata_schemas (user_id, schema)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET schema = EXCLUDED.schema, updated_at = NOW()
+	`
+	if _, err := ir.db.Exec(ctx, sql, userID, schema); err != nil {
+		return fmt.Errorf("set metadata schema: %w", err)
+	}
+	return nil
+}
+
+func (ir *ItemRepository) DeleteMetadataSchema(ctx context.Context, userID uuid.UUID) error {
+	deleted, err := ir.db.Exec(ctx, `DELETE FROM user_metadata_schemas WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("delete metadata schema: %w", err)
+	}
+	if deleted.RowsAffected() == 0 {
+		return ErrMetadataSchemaNotFound
+	}
+	return nil
+}
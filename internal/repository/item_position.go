@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/rank"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrInvalidAnchor = errors.New("items can only be placed next to another item in your list")
+
+// lockPositions serialises position changes for userID's list so two writers
+// never derive the same key.
+func lockPositions(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR NO KEY UPDATE`, userID); err != nil {
+		return fmt.Errorf("lock positions: %w", err)
+	}
+	return nil
+}
+
+// insertPositions puts itemIDs, in order, at the top of userID's list. Items
+// that already have a position keep it.
+func insertPositions(ctx context.Context, tx pgx.Tx, userID uuid.UUID, itemIDs []uuid.UUID) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	if err := lockPositions(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	var first string
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(MIN(position), '') FROM item_positions WHERE user_id = $1`, userID).Scan(&first); err != nil {
+		return fmt.Errorf("insert positions: %w", err)
+	}
+	keys, err := rank.N("", first, len(itemIDs))
+	if err != nil {
+		return fmt.Errorf("insert positions: %w", err)
+	}
+
+	sql := `
+		INSERT INTO item_positions (user_id, item_id, position)
+		SELECT $1, item_id, position
+		FROM unnest($2::uuid[], $3::text[]) AS p(item_id, position)
+		ON CONFLICT (user_id, item_id) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, sql, userID, itemIDs, keys); err != nil {
+		return fmt.Errorf("insert positions: %w", err)
+	}
+	return nil
+}
+
+// MoveItem gives the item a key directly after or before the anchor item in
+// userID's list, or at the top when neither is set. Only the moved item's row is
+// written. The new key is returned.
+func (ir *ItemRepository) MoveItem(ctx context.Context, userID, itemID uuid.UUID, after, before *uuid.UUID) (string, error) {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("move item: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockPositions(ctx, tx, userID); err != nil {
+		return "", err
+	}
+
+	anchor := after
+	if anchor == nil {
+		anchor = before
+	}
+	var anchorKey string
+	if anchor != nil {
+		if *anchor == itemID {
+			return "", ErrInvalidAnchor
+		}
+		sql := `SELECT position FROM item_positions WHERE user_id = $1 AND item_id = $2`
+		if err := tx.QueryRow(ctx, sql, userID, *anchor).Scan(&anchorKey); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return "", ErrInvalidAnchor
+			}
+			return "", fmt.Errorf("move item: %w", err)
+		}
+	}
+
+	// the neighbour on the other side of the gap, ignoring the item being moved
+	var lo, hi string
+	var sql string
+	switch {
+	case after != nil:
+		lo = anchorKey
+		sql = `SELECT COALESCE(MIN(position), '') FROM item_positions WHERE user_id = $1 AND item_id <> $2 AND position > $3`
+		err = tx.QueryRow(ctx, sql, userID, itemID, anchorKey).Scan(&hi)
+	case before != nil:
+		hi = anchorKey
+		sql = `SELECT COALESCE(MAX(position), '') FROM item_positions WHERE user_id = $1 AND item_id <> $2 AND position < $3`
+		err = tx.QueryRow(ctx, sql, userID, itemID, anchorKey).Scan(&lo)
+	default:
+		sql = `SELECT COALESCE(MIN(position), '') FROM item_positions WHERE user_id = $1 AND item_id <> $2`
+		err = tx.QueryRow(ctx, sql, userID, itemID).Scan(&hi)
+	}
+	if err != nil {
+		return "", fmt.Errorf("move item: %w", err)
+	}
+
+	key, err := rank.Between(lo, hi)
+	if err != nil {
+		return "", fmt.Errorf("move item: %w", err)
+	}
+
+	sql = `
+		INSERT INTO item_positions (user_id, item_id, position)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, item_id) DO UPDATE SET position = EXCLUDED.position
+	`
+	if _, err := tx.Exec(ctx, sql, userID, itemID, key); err != nil {
+		return "", fmt.Errorf("move item: %w", err)
+	}
+	return key, tx.Commit(ctx)
+}
+
+// FavoriteItem marks the item as one of userID's favorites; marking it again
+// is a no-op.
+func (ir *ItemRepository) FavoriteItem(ctx context.Context, userID uuid.UUID, itemID string) error {
+	sql := `INSERT INTO item_favorites (user_id, item_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := ir.db.Exec(ctx, sql, userID, itemID); err != nil {
+		return fmt.Errorf("favorite item: %w", err)
+	}
+	return nil
+}
+
+// UnfavoriteItem removes the item from userID's favorites, if it was there.
+func (ir *ItemRepository) UnfavoriteItem(ctx context.Context, userID uuid.UUID, itemID string) error {
+	if _, err := ir.db.Exec(ctx, `DELETE FROM item_favorites WHERE user_id = $1 AND item_id = $2`, userID, itemID); err != nil {
+		return fmt.Errorf("unfavorite item: %w", err)
+	}
+	return nil
+}
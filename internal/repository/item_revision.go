@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+func (ir *ItemRepository) GetRevisions(ctx context.Context, itemID string) ([]model.ItemRevision, error) {
+	sql := `
+		SELECT id, item_id, revision, author_id, title, description, visibility, metadata, changed_fields, created_at
+		FROM item_revisions
+		WHERE item_id = $1
+		ORDER BY revision DESC
+	`
+
+	rows, err := ir.db.Query(ctx, sql, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("get revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []model.ItemRevision{}
+	for rows.Next() {
+		var rev model.ItemRevision
+		if err := rows.Scan(
+			&rev.ID,
+			&rev.ItemID,
+			&rev.Revision,
+			&rev.AuthorID,
+			&rev.Title,
+			&rev.Description,
+			&rev.Visibility,
+			&rev.Metadata,
+			&rev.ChangedFields,
+			&rev.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("get revisions: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (ir *ItemRepository) GetRevision(ctx context.Context, itemID string, revision int) (*model.ItemRevision, error) {
+	return getRevision(ctx, ir.db, itemID, revision)
+}
+
+// RestoreRevision copies the content of an earlier revision back onto the item,
+// including its metadata where the revision recorded it, and its visibility
+// too when withVisibility is set. The restore itself is recorded as a new
+// revision, so history is never rewritten.
+func (ir *ItemRepository) RestoreRevision(ctx context.Context, itemID string, revision int, authorID uuid.UUID, expectedVersion int, withVisibility bool) (int, error) {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("restore revision: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rev, err := getRevision(ctx, tx, itemID, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	restore := model.UpdateItem{
+		Title:       &rev.Title,
+		Description: &rev.Description,
+		Metadata:    rev.Metadata,
+	}
+	if withVisibility {
+		restore.Visibility = rev.Visibility
+	}
+	version, err := updateItemTx(ctx, tx, itemID, authorID, expectedVersion, restore)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, tx.Commit(ctx)
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func getRevision(ctx context.Context, db querier, itemID string, revision int) (*model.ItemRevision, error) {
+	sql := `
+		SELECT id, item_id, revision, author_id, title, description, visibility, metadata, changed_fields, created_at
+		FROM item_revisions
+		WHERE item_id = $1 AND revision = $2
+	`
+
+	var rev model.ItemRevision
+	err := db.QueryRow(ctx, sql, itemID, revision).Scan(
+		&rev.ID,
+		&rev.ItemID,
+		&rev.Revision,
+		&rev.AuthorID,
+		&rev.Title,
+		&rev.Description,
+		&rev.Visibility,
+		&rev.Metadata,
+		&rev.ChangedFields,
+		&rev.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("get revision: %w", err)
+	}
+	return &rev, nil
+}
+
+// updateItemTx locks the item row, checks it against expectedVersion (0 skips the
+// check), writes only the fields that are set and actually differ, bumps version
+// and updated_at, and appends a revision listing the changed fields. Nothing is
+// written when no field changes.
+func updateItemTx(ctx context.Context, tx pgx.Tx, id string, authorID uuid.UUID, expectedVersion int, item model.UpdateItem) (int, error) {
+	var itemID uuid.UUID
+	var title, description, visibility string
+	var version int
+
+	var metadata model.Metadata
+	var metadataChanged bool
+
+	sql := `SELECT id, title, description, visibility, metadata, metadata IS DISTINCT FROM $2::jsonb, version FROM items WHERE id = $1 FOR UPDATE`
+	err := tx.QueryRow(ctx, sql, id, metadataArg(item.Metadata)).Scan(
+		&itemID,
+		&title,
+		&description,
+		&visibility,
+		&metadata,
+		&metadataChanged,
+		&version,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrItemNotFound
+		}
+		return 0, fmt.Errorf("error updating item: %s", err)
+	}
+
+	if expectedVersion != 0 && version != expectedVersion {
+		return 0, ErrVersionMismatch
+	}
+
+	var changed, set []string
+	args := []any{itemID}
+	if item.Title != nil && *item.Title != title {
+		title = *item.Title
+		changed = append(changed, "title")
+		args = append(args, title)
+		set = append(set, fmt.Sprintf("title = $%d", len(args)))
+	}
+	if item.Description != nil && *item.Description != description {
+		description = *item.Description
+		changed = append(changed, "description")
+		args = append(args, description)
+		set = append(set, fmt.Sprintf("description = $%d", len(args)))
+	}
+	if item.Visibility != nil && *item.Visibility != visibility {
+		visibility = *item.Visibility
+		changed = append(changed, "visibility")
+		args = append(args, visibility)
+		set = append(set, fmt.Sprintf("visibility = $%d", len(args)))
+	}
+	if item.Metadata != nil && metadataChanged {
+		metadata = item.Metadata
+		changed = append(changed, "metadata")
+		args = append(args, metadataArg(item.Metadata))
+		set = append(set, fmt.Sprintf("metadata = $%d::jsonb", len(args)))
+	}
+	if len(changed) == 0 {
+		return version, nil
+	}
+
+	sql = `UPDATE items SET ` + strings.Join(set, ", ") + `, version = version + 1, updated_at = NOW() WHERE id = $1 RETURNING version`
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&version); err != nil {
+		return 0, fmt.Errorf("error updating item: %s", err)
+	}
+
+	if err := insertRevision(ctx, tx, itemID, authorID, title, description, visibility, metadata, changed); err != nil {
+		return 0, fmt.Errorf("error updating item: %s", err)
+	}
+	return version, nil
+}
+
+// insertRevision records the item's content after a change: title,
+// description, visibility and metadata, with the fields that changed.
+func insertRevision(ctx context.Context, tx pgx.Tx, itemID, authorID uuid.UUID, title, description, visibility string, metadata model.Metadata, changed []string) error {
+	sql := `
+		INSERT INTO item_revisions (item_id, revision, author_id, title, description, visibility, metadata, changed_fields)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, COALESCE($6::jsonb, '{}'), $7
+		FROM item_revisions
+		WHERE item_id = $1
+	`
+
+	_, err := tx.Exec(ctx, sql, itemID, authorID, title, description, visibility, metadataArg(metadata), changed)
+	if err != nil {
+		return fmt.Errorf("insert revision: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrShareNotFound  = errors.New("share not found")
+	ErrShareUser      = errors.New("no user with that email")
+	ErrShareWithOwner = errors.New("the owner already has full access")
+)
+
+const shareColumns = `s.item_id, s.user_id, u.name, u.email, s.permission, s.created_at, s.updated_at`
+
+func scanShare(row pgx.Row) (*model.ItemShare, error) {
+	var share model.ItemShare
+	err := row.Scan(
+		&share.ItemID,
+		&share.UserID,
+		&share.Name,
+		&share.Email,
+		&share.Permission,
+		&share.CreatedAt,
+		&share.UpdateAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// ItemAccess returns userID's permission on the item ("" when it has none)
+// and the item's visibility.
+func (ir *ItemRepository) ItemAccess(ctx context.Context, itemID string, userID uuid.UUID) (string, string, error) {
+	return itemAccess(ctx, ir.db, itemID, userID, false)
+}
+
+func itemAccess(ctx context.Context, db querier, itemID string, userID uuid.UUID, forUpdate bool) (string, string, error) {
+	sql := `
+		SELECT CASE WHEN items.user_id = $2 THEN 'owner' ELSE COALESCE(s.permission, '') END, items.visibility
+		FROM items
+		LEFT JOIN item_shares s ON s.item_id = items.id AND s.user_id = $2
+		WHERE items.id = $1
+	`
+	if forUpdate {
+		sql += ` FOR UPDATE OF items`
+	}
+
+	var permission, visibility string
+	if err := db.QueryRow(ctx, sql, itemID, userID).Scan(&permission, &visibility); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", "", ErrItemNotFound
+		}
+		return "", "", fmt.Errorf("item access: %w", err)
+	}
+	return permission, visibility, nil
+}
+
+// CheckAccess turns an item's permission and visibility into an access
+// decision for need. Users who cannot see the item at all get ErrItemNotFound
+// so its existence is not revealed; users who can see it, through a share or
+// because it is not private, but lack need get ErrForbidden. Visibility only
+// ever grants reading, and only when publicRead is set.
+func CheckAccess(permission, visibility, need string, publicRead bool) error {
+	if permission == "" {
+		if visibility == model.VisibilityPrivate {
+			return ErrItemNotFound
+		}
+		if publicRead && need == model.PermissionViewer {
+			return nil
+		}
+		return ErrForbidden
+	}
+	if !model.PermissionAllows(permission, need) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// visibleItem is the SQL condition under which the user bound to placeholder
+// can see a row of items: they own it, it is shared with them or it is not private.
+func visibleItem(placeholder string) string {
+	return `(items.user_id = ` + placeholder + ` OR items.visibility <> 'private'` +
+		` OR EXISTS (SELECT 1 FROM item_shares vs WHERE vs.item_id = items.id AND vs.user_id = ` + placeholder + `))`
+}
+
+// checkPermission locks the item and fails unless userID holds at least need.
+func checkPermission(ctx context.Context, tx pgx.Tx, itemID string, userID uuid.UUID, need string) error {
+	permission, visibility, err := itemAccess(ctx, tx, itemID, userID, true)
+	if err != nil {
+		return err
+	}
+	return CheckAccess(permission, visibility, need, false)
+}
+
+// GetSharedItems returns the items shared with userID, most recently shared first.
+func (ir *ItemRepository) GetSharedItems(ctx context.Context, userID uuid.UUID) ([]model.Item, error) {
+	sql := `
+		SELECT ` + itemColumns + `, s.permission
+		FROM items
+		JOIN item_shares s ON s.item_id = items.id
+		WHERE s.user_id = $1
+		ORDER BY s.created_at DESC
+	`
+
+	rows, err := ir.db.Query(ctx, sql, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get shared items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []model.Item{}
+	for rows.Next() {
+		var permission string
+		item, err := scanItem(rows, &permission)
+		if err != nil {
+			return nil, fmt.Errorf("get shared items: %w", err)
+		}
+		item.Permission = permission
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (ir *ItemRepository) GetShares(ctx context.Context, itemID string) ([]model.ItemShare, error) {
+	sql := `
+		SELECT ` + shareColumns + `
+		FROM item_shares s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.item_id = $1
+		ORDER BY s.created_at
+	`
+
+	rows, err := ir.db.Query(ctx, sql, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("get shares: %w", err)
+	}
+	defer rows.Close()
+
+	shares := []model.ItemShare{}
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get shares: %w", err)
+		}
+		shares = append(shares, *share)
+	}
+	return shares, rows.Err()
+}
+
+// ShareItem grants the user registered under email the permission on the item,
+// replacing any permission they already had.
+func (ir *ItemRepository) ShareItem(ctx context.Context, itemID, email, permission string) (*model.ItemShare, error) {
+	tx, err := ir.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("share item: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id, ownerID uuid.UUID
+	err = tx.QueryRow(ctx, `SELECT id, user_id FROM items WHERE id = $1 FOR UPDATE`, itemID).Scan(&id, &ownerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, fmt.Errorf("share item: %w", err)
+	}
+
+	var granteeID uuid.UUID
+	if err := tx.QueryRow(ctx, `SELECT id FROM users WHERE email = $1`, email).Scan(&granteeID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrShareUser
+		}
+		return nil, fmt.Errorf("share item: %w", err)
+	}
+	if granteeID == ownerID {
+		return nil, ErrShareWithOwner
+	}
+
+	sql := `
+		INSERT INTO item_shares (item_id, user_id, permission)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (item_id, user_id) DO UPDATE
+		SET permission = EXCLUDED.permission, updated_at = NOW()
+	`
+	if _, err := tx.Exec(ctx, sql, itemID, granteeID, permission); err != nil {
+		return nil, fmt.Errorf("share item: %w", err)
+	}
+	// a newly shared item appears at the top of the grantee's list
+	if err := insertPositions(ctx, tx, granteeID, []uuid.UUID{id}); err != nil {
+		return nil, fmt.Errorf("share item: %w", err)
+	}
+
+	sql = `
+		SELECT ` + shareColumns + `
+		FROM item_shares s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.item_id = $1 AND s.user_id = $2
+	`
+	share, err := scanShare(tx.QueryRow(ctx, sql, itemID, granteeID))
+	if err != nil {
+		return nil, fmt.Errorf("share item: %w", err)
+	}
+	return share, tx.Commit(ctx)
+}
+
+// DeleteShare revokes the user's access to the item and drops the item from
+// their favorites and list order.
+func (ir *ItemRepository) DeleteShare(ctx context.Context, itemID string, userID uuid.UUID) error {
+	sql := `
+		WITH share AS (
+			DELETE FROM item_shares WHERE item_id = $1 AND user_id = $2 RETURNING user_id
+		), favorite AS (
+			DELETE FROM item_favorites WHERE item_id = $1 AND user_id IN (SELECT user_id FROM share)
+		), ordering AS (
+			DELETE FROM item_positions WHERE item_id = $1 AND user_id IN (SELECT user_id FROM share)
+		)
+		SELECT COUNT(*) FROM share
+	`
+	var deleted int
+	if err := ir.db.QueryRow(ctx, sql, itemID, userID).Scan(&deleted); err != nil {
+		return fmt.Errorf("delete share: %w", err)
+	}
+	if deleted == 0 {
+		return ErrShareNotFound
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+const templateColumns = `id, user_id, name, title, description, visibility, metadata, created_at, updated_at`
+
+func scanTemplate(row pgx.Row) (*model.ItemTemplate, error) {
+	var template model.ItemTemplate
+	err := row.Scan(
+		&template.ID,
+		&template.UserID,
+		&template.Name,
+		&template.Title,
+		&template.Description,
+		&template.Visibility,
+		&template.Metadata,
+		&template.CreatedAt,
+		&template.UpdateAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (ir *ItemRepository) CreateTemplate(ctx context.Context, template *model.ItemTemplate) error {
+	sql := `
+		INSERT INTO item_templates (user_id, name, title, description, visibility, metadata)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6::jsonb, '{}'))
+		RETURNING ` + templateColumns
+
+	created, err := scanTemplate(ir.db.QueryRow(ctx, sql,
+		template.UserID,
+		template.Name,
+		template.Title,
+		template.Description,
+		template.Visibility,
+		metadataArg(template.Metadata),
+	))
+	if err != nil {
+		return fmt.Errorf("create template: %w", err)
+	}
+	*template = *created
+	return nil
+}
+
+// GetTemplates returns userID's templates ordered by name.
+func (ir *ItemRepository) GetTemplates(ctx context.Context, userID uuid.UUID) ([]model.ItemTemplate, error) {
+	sql := `SELECT ` + templateColumns + ` FROM item_templates WHERE user_id = $1 ORDER BY name, created_at`
+
+	rows, err := ir.db.Query(ctx, sql, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []model.ItemTemplate{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get templates: %w", err)
+		}
+		templates = append(templates, *template)
+	}
+	return templates, rows.Err()
+}
+
+// GetTemplate returns one of userID's templates; other users' templates are
+// reported as not found.
+func (ir *ItemRepository) GetTemplate(ctx context.Context, id string, userID uuid.UUID) (*model.ItemTemplate, error) {
+	sql := `SELECT ` + templateColumns + ` FROM item_templates WHERE id = $1 AND user_id = $2`
+
+	template, err := scanTemplate(ir.db.QueryRow(ctx, sql, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("get template: %w", err)
+	}
+	return template, nil
+}
+
+func (ir *ItemRepository) DeleteTemplate(ctx context.Context, id string, userID uuid.UUID) error {
+	deleted, err := ir.db.Exec(ctx, `DELETE FROM item_templates WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete template: %w", err)
+	}
+	if deleted.RowsAffected() == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
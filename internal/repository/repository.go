@@ -5,15 +5,23 @@ import (
 )
 
 type Repository struct {
-	User    *UserRepository
-	Item    *ItemRepository
-	Session *SessionRepository
+	User        *UserRepository
+	Item        *ItemRepository
+	Session     *SessionRepository
+	ImportJob   *ImportJobRepository
+	Idempotency *IdempotencyRepository
+	Collection  *CollectionRepository
+	Upload      *UploadRepository
 }
 
 func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{
-		User:    NewUserRepository(pool),
-		Item:    NewItemRepository(pool),
-		Session: NewSessionRepository(pool),
+		User:        NewUserRepository(pool),
+		Item:        NewItemRepository(pool),
+		Session:     NewSessionRepository(pool),
+		ImportJob:   NewImportJobRepository(pool),
+		Idempotency: NewIdempotencyRepository(pool),
+		Collection:  NewCollectionRepository(pool),
+		Upload:      NewUploadRepository(pool),
 	}
 }
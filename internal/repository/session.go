@@ -64,7 +64,8 @@ func (r *SessionRepository) GetUserBySessionID(
 	SELECT 
 		u.id,
 		u.name,
-		u.email
+		u.email,
+		u.is_admin
 	FROM sessions s
 	INNER JOIN users u ON s.user_id = u.id
 	WHERE s.session_id = $1
@@ -82,6 +83,7 @@ func (r *SessionRepository) GetUserBySessionID(
 		&user.ID,
 		&user.Name,
 		&user.Email,
+		&user.IsAdmin,
 	)
 
 	if err != nil {
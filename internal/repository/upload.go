@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrUploadOffset   = errors.New("upload offset does not match")
+)
+
+type UploadRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUploadRepository(db *pgxpool.Pool) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+const uploadColumns = `id, user_id, file_name, length, upload_offset, metadata, chunks, expires_at, created_at, updated_at`
+
+func scanUpload(row pgx.Row) (*model.Upload, error) {
+	var upload model.Upload
+	err := row.Scan(
+		&upload.ID,
+		&upload.UserID,
+		&upload.FileName,
+		&upload.Length,
+		&upload.Offset,
+		&upload.Metadata,
+		&upload.Chunks,
+		&upload.ExpiresAt,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (ur *UploadRepository) CreateUpload(ctx context.Context, upload *model.Upload) error {
+	sql := `
+		INSERT INTO uploads (user_id, file_name, length, metadata, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + uploadColumns
+
+	created, err := scanUpload(ur.db.QueryRow(ctx, sql,
+		upload.UserID,
+		upload.FileName,
+		upload.Length,
+		upload.Metadata,
+		upload.ExpiresAt,
+	))
+	if err != nil {
+		return fmt.Errorf("create upload: %w", err)
+	}
+	*upload = *created
+	return nil
+}
+
+// GetUpload returns the upload only if it belongs to userID and has not expired.
+func (ur *UploadRepository) GetUpload(ctx context.Context, id, userID uuid.UUID) (*model.Upload, error) {
+	sql := `SELECT ` + uploadColumns + ` FROM uploads WHERE id = $1 AND user_id = $2 AND expires_at > NOW()`
+
+	upload, err := scanUpload(ur.db.QueryRow(ctx, sql, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("get upload: %w", err)
+	}
+	return upload, nil
+}
+
+// AppendChunk records a stored chunk of size bytes written at offset and moves
+// the upload's expiry to expiresAt. It fails with ErrUploadOffset when another
+// request has moved the offset meanwhile, or the chunk would overrun the
+// upload's length.
+func (ur *UploadRepository) AppendChunk(ctx context.Context, upload *model.Upload, chunk string, size int64, expiresAt time.Time) error {
+	sql := `
+		UPDATE uploads
+		SET upload_offset = upload_offset + $4, chunks = array_append(chunks, $5), expires_at = $6, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND upload_offset = $3 AND upload_offset + $4 <= length
+		RETURNING ` + uploadColumns
+
+	updated, err := scanUpload(ur.db.QueryRow(ctx, sql, upload.ID, upload.UserID, upload.Offset, size, chunk, expiresAt))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUploadOffset
+		}
+		return fmt.Errorf("append upload chunk: %w", err)
+	}
+	*upload = *updated
+	return nil
+}
+
+// DeleteUpload removes the upload and returns it, so its chunks can be deleted.
+func (ur *UploadRepository) DeleteUpload(ctx context.Context, id, userID uuid.UUID) (*model.Upload, error) {
+	sql := `DELETE FROM uploads WHERE id = $1 AND user_id = $2 RETURNING ` + uploadColumns
+
+	upload, err := scanUpload(ur.db.QueryRow(ctx, sql, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("delete upload: %w", err)
+	}
+	return upload, nil
+}
+
+// PurgeExpired deletes uploads whose expiry has passed and returns their chunks.
+func (ur *UploadRepository) PurgeExpired(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := ur.db.Query(ctx, `DELETE FROM uploads WHERE expires_at <= $1 RETURNING chunks`, now)
+	if err != nil {
+		return nil, fmt.Errorf("purge uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []string
+	for rows.Next() {
+		var uploadChunks []string
+		if err := rows.Scan(&uploadChunks); err != nil {
+			return nil, fmt.Errorf("purge uploads: %w", err)
+		}
+		chunks = append(chunks, uploadChunks...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("purge uploads: %w", err)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrStorageQuota = errors.New("storage quota exceeded")
+	ErrItemQuota    = errors.New("item quota exceeded")
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// reserveQuota checks that userID has room for items more items and bytes more
+// bytes under quota, or under the user's override of it. The usage row stays
+// locked until tx ends, so concurrent writes by the same user cannot both
+// squeeze into the last bit of room; the triggers then record the write.
+func reserveQuota(ctx context.Context, tx pgx.Tx, userID uuid.UUID, quota model.Quota, items int, bytes int64) error {
+	usage, err := lockUsage(ctx, tx, userID, quota)
+	if err != nil {
+		return err
+	}
+	limit := usage.Quota
+
+	if items > 0 && limit.MaxItems > 0 && usage.Items+items > limit.MaxItems {
+		return fmt.Errorf("%w: %d of %d items used", ErrItemQuota, usage.Items, limit.MaxItems)
+	}
+	if bytes > 0 && limit.MaxBytes > 0 && usage.Bytes+bytes > limit.MaxBytes {
+		return fmt.Errorf("%w: %d of %d bytes used, %d more needed", ErrStorageQuota, usage.Bytes, limit.MaxBytes, bytes)
+	}
+	return nil
+}
+
+// lockUsage reads userID's usage and the quota that applies to it, locking the
+// usage row until tx ends.
+func lockUsage(ctx context.Context, tx pgx.Tx, userID uuid.UUID, quota model.Quota) (*model.Usage, error) {
+	sql := `
+		INSERT INTO user_usage (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, sql, userID); err != nil {
+		return nil, fmt.Errorf("lock usage: %w", err)
+	}
+
+	var usage model.Usage
+	if err := tx.QueryRow(ctx, lockUsageQuery, userID).Scan(usageFields(&usage)...); err != nil {
+		return nil, fmt.Errorf("lock usage: %w", err)
+	}
+	usage.Quota = effectiveQuota(quota, usage.Override)
+	return &usage, nil
+}
+
+// GetUsage reports what userID's items take up and the quota that applies to
+// them, which is quota unless an admin has overridden it.
+func (ir *ItemRepository) GetUsage(ctx context.Context, userID uuid.UUID, quota model.Quota) (*model.Usage, error) {
+	var usage model.Usage
+	if err := ir.db.QueryRow(ctx, usageQuery, userID).Scan(usageFields(&usage)...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("get usage: %w", err)
+	}
+	usage.Quota = effectiveQuota(quota, usage.Override)
+	return &usage, nil
+}
+
+// SetQuota replaces userID's quota override; nil fields go back to the
+// configured quota.
+func (ir *ItemRepository) SetQuota(ctx context.Context, userID uuid.UUID, override model.QuotaOverride) error {
+	sql := `UPDATE users SET quota_bytes = $2, quota_items = $3, updated_at = NOW() WHERE id = $1`
+	tag, err := ir.db.Exec(ctx, sql, userID, override.MaxBytes, override.MaxItems)
+	if err != nil {
+		return fmt.Errorf("set quota: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// itemOwner returns the owner of an item locked by lockItem, whose quota its
+// files count against.
+func itemOwner(ctx context.Context, tx pgx.Tx, itemID string) (uuid.UUID, error) {
+	var owner uuid.UUID
+	if err := tx.QueryRow(ctx, `SELECT user_id FROM items WHERE id = $1`, itemID).Scan(&owner); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrItemNotFound
+		}
+		return uuid.Nil, fmt.Errorf("item owner: %w", err)
+	}
+	return owner, nil
+}
+
+// usageQuery reads a user's usage, which is zero before their first item.
+const usageQuery = `
+	SELECT u.id, COALESCE(uu.bytes, 0), COALESCE(uu.items, 0), u.quota_bytes, u.quota_items
+	FROM users u
+	LEFT JOIN user_usage uu ON uu.user_id = u.id
+	WHERE u.id = $1`
+
+// lockUsageQuery reads and locks a usage row that is known to exist.
+const lockUsageQuery = `
+	SELECT u.id, uu.bytes, uu.items, u.quota_bytes, u.quota_items
+	FROM users u
+	JOIN user_usage uu ON uu.user_id = u.id
+	WHERE u.id = $1
+	FOR UPDATE OF uu`
+
+func usageFields(usage *model.Usage) []any {
+	return []any{&usage.UserID, &usage.Bytes, &usage.Items, &usage.Override.MaxBytes, &usage.Override.MaxItems}
+}
+
+func effectiveQuota(quota model.Quota, override model.QuotaOverride) model.Quota {
+	if override.MaxBytes != nil {
+		quota.MaxBytes = *override.MaxBytes
+	}
+	if override.MaxItems != nil {
+		quota.MaxItems = *override.MaxItems
+	}
+	return quota
+}
@@ -0,0 +1,73 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/httprate"
+)
+
+// Requests per minute and client IP. Images and resumable uploads have their
+// own, much higher budgets: a list view loads one thumbnail per item, and a
+// client on a flaky network sends many HEAD and PATCH requests per upload.
+const (
+	apiRequestsPerMinute    = 10
+	imageRequestsPerMinute  = 600
+	uploadRequestsPerMinute = 300
+)
+
+// routeLimit gives the requests match accepts their own rate limit.
+type routeLimit struct {
+	match func(r *http.Request) bool
+	limit func(http.Handler) http.Handler
+}
+
+// rateLimit limits each request by the first route limit matching it, or by
+// fallback. Every limit counts its requests separately.
+func rateLimit(fallback func(http.Handler) http.Handler, routes ...routeLimit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limited := make([]http.Handler, len(routes))
+		for i, route := range routes {
+			limited[i] = route.limit(next)
+		}
+		other := fallback(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i, route := range routes {
+				if route.match(r) {
+					limited[i].ServeHTTP(w, r)
+					return
+				}
+			}
+			other.ServeHTTP(w, r)
+		})
+	}
+}
+
+func limitPerMinute(requests int) func(http.Handler) http.Handler {
+	return httprate.Limit(
+		requests,
+		time.Minute,
+		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error": "Rate-limited. Please, slow down."}`, http.StatusTooManyRequests)
+		}),
+	)
+}
+
+func isUploadRequest(r *http.Request) bool {
+	return r.URL.Path == "/api/v1/uploads" || strings.HasPrefix(r.URL.Path, "/api/v1/uploads/")
+}
+
+// isImageRequest matches reads of item images in every form: signed URLs, the
+// image of an item, public item or link, and the endpoint handing out signed
+// URLs. Replacing or deleting an image stays under the general limit.
+func isImageRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	path := r.URL.Path
+	return strings.HasPrefix(path, "/images/") ||
+		strings.HasSuffix(path, "/image") ||
+		strings.HasSuffix(path, "/image/url")
+}
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteLimitMatch(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		image  bool
+		upload bool
+	}{
+		{"GET", "/api/v1/items/1/image", true, false},
+		{"HEAD", "/api/v1/items/1/image", true, false},
+		{"GET", "/api/v1/items/1/image/url", true, false},
+		{"GET", "/images/1", true, false},
+		{"GET", "/public/items/1/image", true, false},
+		{"PUT", "/api/v1/items/1/image", false, false},
+		{"DELETE", "/api/v1/items/1/image", false, false},
+		{"GET", "/api/v1/items/1", false, false},
+		{"POST", "/api/v1/uploads", false, true},
+		{"PATCH", "/api/v1/uploads/1", false, true},
+		{"HEAD", "/api/v1/uploads/1", false, true},
+		{"GET", "/api/v1/uploadsx", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if got := isImageRequest(r); got != tt.image {
+				t.Errorf("isImageRequest = %v, want %v", got, tt.image)
+			}
+			if got := isUploadRequest(r); got != tt.upload {
+				t.Errorf("isUploadRequest = %v, want %v", got, tt.upload)
+			}
+		})
+	}
+}
@@ -1,14 +1,9 @@
 package router
 
 import (
-	"net/http"
-	"time"
-
 	"mastery-project/internal/handler"
 	authMiddleware "mastery-project/internal/middleware"
 
-	"github.com/go-chi/httprate"
-
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -16,6 +11,7 @@ import (
 func NewRouter(
 	h *handler.Handlers,
 	authMW *authMiddleware.AuthMiddleware,
+	idempotencyMW *authMiddleware.IdempotencyMiddleware,
 ) chi.Router {
 
 	r := chi.NewRouter()
@@ -25,24 +21,18 @@ func NewRouter(
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(middleware.GetHead)
 
 	//rate limit
-	r.Use(httprate.Limit(
-		10,
-		time.Minute,
-		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, `{"error": "Rate-limited. Please, slow down."}`, http.StatusTooManyRequests)
-		}),
+	r.Use(rateLimit(
+		limitPerMinute(apiRequestsPerMinute),
+		routeLimit{match: isImageRequest, limit: limitPerMinute(imageRequestsPerMinute)},
+		routeLimit{match: isUploadRequest, limit: limitPerMinute(uploadRequestsPerMinute)},
 	))
 
-	//Static uploads (public)
-	r.Handle(
-		"/uploads/*",
-		http.StripPrefix(
-			"/uploads/",
-			http.FileServer(http.Dir("uploads")),
-		),
-	)
+	//Public item links and signed image URLs
+	registerLinkRoutes(r, h)
+	registerImageRoutes(r, h)
 
 	//API v1
 	r.Route("/api/v1", func(r chi.Router) {
@@ -52,9 +42,26 @@ func NewRouter(
 			registerAuthRoutes(r, h)
 		})
 
+		//Public items
+		r.Route("/public", func(r chi.Router) {
+			registerPublicRoutes(r, h)
+		})
+
+		//tus capability discovery
+		registerUploadDiscoveryRoutes(r, h)
+
 		//Protected routes
 		r.With(authMW.Protected).Group(func(r chi.Router) {
-			registerItemRoutes(r, h)
+			r.With(idempotencyMW.Handle).Group(func(r chi.Router) {
+				registerItemRoutes(r, h)
+				registerCollectionRoutes(r, h)
+				registerTemplateRoutes(r, h)
+			})
+			registerMeRoutes(r, h)
+			registerUploadRoutes(r, h)
+			r.With(authMW.Admin).Group(func(r chi.Router) {
+				registerAdminRoutes(r, h)
+			})
 		})
 	})
 
@@ -19,11 +19,146 @@ func registerItemRoutes(r chi.Router, h *handler.Handlers) {
 	r.Route("/items", func(r chi.Router) {
 		r.Get("/", h.Item.GetAll)
 		r.Post("/", h.Item.Create)
+		r.Post("/bulk", h.Item.Bulk)
+		r.Get("/export", h.Import.Export)
+		r.Post("/import", h.Import.Import)
+		r.Get("/import/{jobID}", h.Import.Job)
+		r.Get("/shared", h.Item.SharedWithMe)
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", h.Item.GetOne)
 			r.Patch("/", h.Item.Update)
 			r.Delete("/", h.Item.Delete)
+			r.Post("/favorite", h.Item.Favorite)
+			r.Delete("/favorite", h.Item.Unfavorite)
+			r.Put("/position", h.Item.Move)
+			r.Post("/duplicate", h.Item.Duplicate)
+			r.Post("/template", h.Item.SaveTemplate)
+			r.Get("/image", h.Item.Image)
+			r.Get("/image/url", h.Item.ImageURL)
+			r.Put("/image", h.Item.ReplaceImage)
+			r.Delete("/image", h.Item.DeleteImage)
+
+			r.Route("/attachments", func(r chi.Router) {
+				r.Get("/", h.Item.ListAttachments)
+				r.Post("/", h.Item.AddAttachment)
+				r.Put("/order", h.Item.ReorderAttachments)
+				r.Delete("/{attachmentID}", h.Item.DeleteAttachment)
+				r.Get("/{attachmentID}/file", h.Item.AttachmentFile)
+			})
+
+			r.Route("/revisions", func(r chi.Router) {
+				r.Get("/", h.Item.ListRevisions)
+				r.Get("/diff", h.Item.DiffRevisions)
+				r.Get("/{rev}", h.Item.GetRevision)
+				r.Post("/{rev}/restore", h.Item.RestoreRevision)
+			})
+
+			r.Route("/comments", func(r chi.Router) {
+				r.Get("/", h.Item.ListComments)
+				r.Post("/", h.Item.AddComment)
+				r.Patch("/{commentID}", h.Item.EditComment)
+				r.Delete("/{commentID}", h.Item.DeleteComment)
+			})
+
+			r.Route("/links", func(r chi.Router) {
+				r.Get("/", h.Item.ListLinks)
+				r.Post("/", h.Item.CreateLink)
+				r.Delete("/{linkID}", h.Item.RevokeLink)
+			})
+
+			r.Route("/shares", func(r chi.Router) {
+				r.Get("/", h.Item.ListShares)
+				r.Post("/", h.Item.Share)
+				r.Delete("/{userID}", h.Item.Unshare)
+			})
+		})
+	})
+}
+
+func registerTemplateRoutes(r chi.Router, h *handler.Handlers) {
+	r.Route("/templates", func(r chi.Router) {
+		r.Get("/", h.Item.ListTemplates)
+
+		r.Route("/{templateID}", func(r chi.Router) {
+			r.Get("/", h.Item.GetTemplate)
+			r.Delete("/", h.Item.DeleteTemplate)
+			r.Post("/items", h.Item.CreateFromTemplate)
 		})
 	})
 }
+
+func registerMeRoutes(r chi.Router, h *handler.Handlers) {
+	r.Route("/me", func(r chi.Router) {
+		r.Get("/metadata-schema", h.Item.GetMetadataSchema)
+		r.Put("/metadata-schema", h.Item.PutMetadataSchema)
+		r.Delete("/metadata-schema", h.Item.DeleteMetadataSchema)
+		r.Get("/usage", h.Item.Usage)
+	})
+}
+
+func registerAdminRoutes(r chi.Router, h *handler.Handlers) {
+	r.Route("/admin/users/{id}", func(r chi.Router) {
+		r.Get("/usage", h.Item.UserUsage)
+		r.Put("/quota", h.Item.SetUserQuota)
+	})
+}
+
+func registerCollectionRoutes(r chi.Router, h *handler.Handlers) {
+	r.Route("/collections", func(r chi.Router) {
+		r.Get("/", h.Collection.List)
+		r.Post("/", h.Collection.Create)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.Collection.Get)
+			r.Patch("/", h.Collection.Update)
+			r.Delete("/", h.Collection.Delete)
+			r.Get("/items", h.Collection.Items)
+			r.Post("/items", h.Collection.AddItems)
+			r.Post("/items/move", h.Collection.MoveItems)
+			r.Delete("/items/{itemID}", h.Collection.RemoveItem)
+		})
+	})
+}
+
+func registerPublicRoutes(r chi.Router, h *handler.Handlers) {
+	r.Get("/items", h.Item.PublicItems)
+	r.Get("/items/{id}", h.Item.PublicItem)
+	r.Get("/items/{id}/image", h.Item.PublicImage)
+	r.Get("/items/{id}/attachments/{attachmentID}/file", h.Item.PublicAttachmentFile)
+}
+
+// registerLinkRoutes serves public item links; they need no session.
+func registerLinkRoutes(r chi.Router, h *handler.Handlers) {
+	r.Route("/s/{token}", func(r chi.Router) {
+		r.Get("/", h.Item.ViewLink)
+		r.Post("/", h.Item.ViewLink)
+		r.Get("/image", h.Item.LinkImage)
+	})
+}
+
+// registerImageRoutes serves item images through signed URLs, so <img> tags
+// can load them without an Authorization header.
+func registerImageRoutes(r chi.Router, h *handler.Handlers) {
+	r.Get("/images/{id}", h.Item.SignedImage)
+}
+
+// registerUploadRoutes serves the tus resumable upload protocol. Offsets make
+// PATCH requests safe to retry, so these routes skip the idempotency middleware.
+func registerUploadRoutes(r chi.Router, h *handler.Handlers) {
+	r.With(h.Upload.Protocol).Group(func(r chi.Router) {
+		r.Post("/uploads", h.Upload.Create)
+		r.Head("/uploads/{id}", h.Upload.Head)
+		r.Patch("/uploads/{id}", h.Upload.Patch)
+		r.Delete("/uploads/{id}", h.Upload.Delete)
+	})
+}
+
+// registerUploadDiscoveryRoutes lets tus clients discover the server's
+// capabilities without signing in.
+func registerUploadDiscoveryRoutes(r chi.Router, h *handler.Handlers) {
+	r.With(h.Upload.Protocol).Group(func(r chi.Router) {
+		r.Options("/uploads", h.Upload.Options)
+		r.Options("/uploads/{id}", h.Upload.Options)
+	})
+}
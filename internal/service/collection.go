@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type CollectionService struct {
+	CollectionRepo *repository.CollectionRepository
+}
+
+func NewCollectionService(collectionRepo *repository.CollectionRepository) *CollectionService {
+	return &CollectionService{CollectionRepo: collectionRepo}
+}
+
+func (cs *CollectionService) Create(ctx context.Context, userID uuid.UUID, req model.CreateCollection) (*model.Collection, error) {
+	collection := &model.Collection{
+		UserID:      userID,
+		ParentID:    req.ParentID,
+		Name:        req.Name,
+		Description: req.Description,
+		CoverItemID: req.CoverItemID,
+	}
+	if err := cs.CollectionRepo.Create(ctx, collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (cs *CollectionService) Get(ctx context.Context, id string, userID uuid.UUID) (*model.Collection, error) {
+	return cs.CollectionRepo.Get(ctx, id, userID)
+}
+
+func (cs *CollectionService) List(ctx context.Context, userID uuid.UUID, parentID *uuid.UUID, topLevel bool) ([]model.Collection, error) {
+	return cs.CollectionRepo.List(ctx, userID, parentID, topLevel)
+}
+
+func (cs *CollectionService) Update(ctx context.Context, id, userID uuid.UUID, update model.UpdateCollection) (*model.Collection, error) {
+	if err := cs.CollectionRepo.Update(ctx, id, userID, update); err != nil {
+		return nil, err
+	}
+	return cs.CollectionRepo.Get(ctx, id.String(), userID)
+}
+
+func (cs *CollectionService) Delete(ctx context.Context, id string, userID uuid.UUID) error {
+	return cs.CollectionRepo.Delete(ctx, id, userID)
+}
+
+func (cs *CollectionService) Items(ctx context.Context, id string, userID uuid.UUID, limit, offset int) (*model.CollectionItemsPage, error) {
+	items, total, err := cs.CollectionRepo.Items(ctx, id, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CollectionItemsPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+func (cs *CollectionService) AddItems(ctx context.Context, id, userID uuid.UUID, itemIDs []uuid.UUID) error {
+	return cs.CollectionRepo.AddItems(ctx, id, userID, itemIDs)
+}
+
+func (cs *CollectionService) RemoveItem(ctx context.Context, id, userID, itemID uuid.UUID) error {
+	return cs.CollectionRepo.RemoveItem(ctx, id, userID, itemID)
+}
+
+func (cs *CollectionService) MoveItems(ctx context.Context, from, userID uuid.UUID, req model.MoveItemsRequest) error {
+	return cs.CollectionRepo.MoveItems(ctx, from, req.To, userID, req.ItemIDs)
+}
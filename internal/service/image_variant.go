@@ -0,0 +1,228 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mastery-project/internal/config"
+	"mastery-project/internal/imaging"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"mastery-project/internal/storage"
+	"sync"
+)
+
+var ErrUnknownVariant = errors.New("unknown image size")
+
+// VariantService renders the configured variants of uploaded item images in a
+// pool of background workers and stores them next to the originals.
+type VariantService struct {
+	ItemRepo *repository.ItemRepository
+	blobs    storage.Blob
+	variants []imaging.Variant
+	limits   imaging.Limits
+	workers  int
+	jobs     chan string
+}
+
+func NewVariantService(itemRepo *repository.ItemRepository, blobs storage.Blob, cfg config.Images) (*VariantService, error) {
+	variants, err := imaging.ParseVariants(cfg.Variants)
+	if err != nil {
+		return nil, err
+	}
+	return &VariantService{
+		ItemRepo: itemRepo,
+		blobs:    blobs,
+		variants: variants,
+		limits:   imaging.Limits{MaxDimension: cfg.MaxDimension, MaxPixels: cfg.MaxPixels},
+		workers:  max(1, cfg.Workers),
+		jobs:     make(chan string, max(1, cfg.QueueSize)),
+	}, nil
+}
+
+// Run processes queued images until ctx is done. Images that are missing a
+// variant, because they were uploaded before it was configured or their job
+// was lost in a restart, are queued first.
+func (vs *VariantService) Run(ctx context.Context) {
+	if len(vs.variants) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for range vs.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case file := <-vs.jobs:
+					if err := vs.process(ctx, file); err != nil && ctx.Err() == nil {
+						slog.Error("rendering image variants failed", "file", file, "err", err)
+					}
+				}
+			}
+		}()
+	}
+
+	missing, err := vs.ItemRepo.ImagesMissingVariants(ctx, vs.Names())
+	if err != nil {
+		slog.Error("finding images without variants failed", "err", err)
+	}
+	for _, file := range missing {
+		select {
+		case vs.jobs <- file:
+		case <-ctx.Done():
+		}
+	}
+	wg.Wait()
+}
+
+// Enqueue schedules the variants of a newly stored image. It never blocks the
+// upload: when the queue is full the image is picked up on the next start.
+func (vs *VariantService) Enqueue(file string) {
+	if file == "" || len(vs.variants) == 0 {
+		return
+	}
+	select {
+	case vs.jobs <- file:
+	default:
+		slog.Warn("image variant queue is full, deferring to the next start", "file", file)
+	}
+}
+
+// Names lists the configured variants.
+func (vs *VariantService) Names() []string {
+	names := make([]string, len(vs.variants))
+	for i, variant := range vs.variants {
+		names[i] = variant.Name
+	}
+	return names
+}
+
+// Variant returns the named variant of the image stored in file. It fails with
+// ErrUnknownVariant for a size that is not configured and with
+// repository.ErrImageVariantNotFound while the variant is not rendered yet.
+func (vs *VariantService) Variant(ctx context.Context, file, name string) (*model.ImageVariant, error) {
+	if !vs.known(name) {
+		return nil, ErrUnknownVariant
+	}
+	return vs.ItemRepo.GetImageVariant(ctx, file, name)
+}
+
+// Attach fills in the URLs of the rendered variants of each item's image.
+func (vs *VariantService) Attach(ctx context.Context, items []model.Item) error {
+	var files []string
+	for _, item := range items {
+		if item.FilePath != "" {
+			files = append(files, item.FilePath)
+		}
+	}
+	variants, err := vs.ItemRepo.GetImageVariants(ctx, files)
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		for _, variant := range variants[items[i].FilePath] {
+			if !vs.known(variant.Name) {
+				continue
+			}
+			if items[i].ImageVariants == nil {
+				items[i].ImageVariants = make(map[string]string)
+			}
+			items[i].ImageVariants[variant.Name] = model.ImageVariantURL(items[i].ID, variant.Name)
+		}
+	}
+	return nil
+}
+
+// Remove deletes the variants of an original that is being deleted, including
+// ones a worker stored but has not recorded yet.
+func (vs *VariantService) Remove(ctx context.Context, file string) {
+	fileNames, err := vs.ItemRepo.DeleteImageVariants(ctx, file)
+	if err != nil {
+		slog.Warn("could not delete image variants", "file", file, "err", err)
+	}
+	for _, variant := range vs.variants {
+		fileNames = append(fileNames, variant.Key(file))
+	}
+	for _, name := range fileNames {
+		if err := vs.blobs.Delete(ctx, name); err != nil && !errors.Is(err, storage.ErrInvalidKey) {
+			slog.Warn("could not delete image variant", "file", name, "err", err)
+		}
+	}
+}
+
+// process renders and stores every variant of one image.
+func (vs *VariantService) process(ctx context.Context, file string) error {
+	reader, _, err := vs.blobs.Get(ctx, file)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	if checksum, ok := storage.KeyChecksum(file); ok {
+		if err := storage.Verify(data, checksum); err != nil {
+			return err
+		}
+	}
+	img, err := vs.limits.Decode(data)
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	for _, variant := range vs.variants {
+		rendered := variant.Render(img)
+		var buf bytes.Buffer
+		if err := variant.Encode(&buf, rendered); err != nil {
+			return fmt.Errorf("encode %s: %w", variant.Name, err)
+		}
+		sum := sha256.Sum256(buf.Bytes())
+
+		record := &model.ImageVariant{
+			FilePath: file,
+			Name:     variant.Name,
+			FileName: variant.Key(file),
+			Width:    rendered.Bounds().Dx(),
+			Height:   rendered.Bounds().Dy(),
+			Size:     int64(buf.Len()),
+			MimeType: imaging.ContentType(variant.Format),
+			Checksum: hex.EncodeToString(sum[:]),
+		}
+		if err := vs.blobs.Put(ctx, record.FileName, &buf, record.Size, record.MimeType); err != nil {
+			return err
+		}
+		if err := vs.ItemRepo.SaveImageVariant(ctx, record); err != nil {
+			return err
+		}
+	}
+
+	// the image may have been deleted while it was being rendered
+	inUse, err := vs.ItemRepo.FileInUse(ctx, file)
+	if err == nil && !inUse {
+		vs.Remove(ctx, file)
+	}
+	return err
+}
+
+func (vs *VariantService) known(name string) bool {
+	for _, variant := range vs.variants {
+		if variant.Name == name {
+			return true
+		}
+	}
+	return false
+}
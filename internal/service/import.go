@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"mastery-project/internal/config"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// importChunkSize is how many rows go into one bulk insert.
+	importChunkSize = 500
+	// maxJobErrors caps the per-line errors a job keeps; the failed count stays exact.
+	maxJobErrors = 1000
+)
+
+type ImportService struct {
+	ItemRepo  *repository.ItemRepository
+	JobRepo   *repository.ImportJobRepository
+	asyncRows int
+	quota     model.Quota
+}
+
+func NewImportService(itemRepo *repository.ItemRepository, jobRepo *repository.ImportJobRepository, cfg config.Items) *ImportService {
+	return &ImportService{
+		ItemRepo:  itemRepo,
+		JobRepo:   jobRepo,
+		asyncRows: cfg.ImportAsyncRows,
+		quota:     configuredQuota(cfg),
+	}
+}
+
+// Export streams every item of the user to fn.
+func (s *ImportService) Export(ctx context.Context, userID uuid.UUID, fn func(*model.Item) error) error {
+	return s.ItemRepo.StreamUserItems(ctx, userID, fn)
+}
+
+// RunsAsync reports whether an import of this many rows becomes a background job.
+func (s *ImportService) RunsAsync(rows int) bool {
+	return s.asyncRows > 0 && rows > s.asyncRows
+}
+
+// CheckMetadata fails with ErrMetadataInvalid when imported items, which get
+// empty metadata, would not satisfy userID's metadata schema.
+func (s *ImportService) CheckMetadata(ctx context.Context, userID uuid.UUID) error {
+	return validateMetadata(ctx, s.ItemRepo, userID, model.Metadata{})
+}
+
+// Import inserts already validated rows and reports the ones the database rejected.
+func (s *ImportService) Import(ctx context.Context, userID uuid.UUID, rows []model.ImportRow) (int, []model.ImportError, error) {
+	imported := 0
+	var failures []model.ImportError
+	for start := 0; start < len(rows); start += importChunkSize {
+		end := min(start+importChunkSize, len(rows))
+		n, errs, err := s.importChunk(ctx, userID, rows[start:end])
+		if err != nil {
+			return imported, failures, err
+		}
+		imported += n
+		failures = append(failures, errs...)
+	}
+	return imported, failures, nil
+}
+
+func (s *ImportService) importChunk(ctx context.Context, userID uuid.UUID, rows []model.ImportRow) (int, []model.ImportError, error) {
+	ops := make([]model.BulkOperation, len(rows))
+	for i := range rows {
+		ops[i] = model.BulkOperation{
+			Op:          "create",
+			Title:       &rows[i].Title,
+			Description: &rows[i].Description,
+			Index:       i,
+		}
+	}
+
+	// imported items get empty metadata, which must satisfy the user's schema
+	rejected, ops, err := rejectCreates(ctx, s.ItemRepo, userID, ops)
+	if err != nil {
+		return 0, nil, err
+	}
+	outcome := &repository.BulkOutcome{}
+	if len(ops) > 0 {
+		outcome, err = s.ItemRepo.RunBulk(ctx, userID, ops, false, s.quota)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	imported := 0
+	var failures []model.ImportError
+	for _, result := range append(rejected, outcome.Results...) {
+		if result.Err != nil {
+			failures = append(failures, model.ImportError{Line: rows[result.Index].Line, Error: result.Err.Error()})
+			continue
+		}
+		imported++
+	}
+	return imported, failures, nil
+}
+
+// StartJob records an import job and inserts the rows in the background.
+// invalid holds the rows that already failed validation; total counts every
+// row of the file, valid or not.
+func (s *ImportService) StartJob(ctx context.Context, userID uuid.UUID, format string, rows []model.ImportRow, invalid []model.ImportError, total int) (*model.ImportJob, error) {
+	job := &model.ImportJob{
+		UserID:    userID,
+		Format:    format,
+		Total:     total,
+		Processed: len(invalid),
+		Failed:    len(invalid),
+		Errors:    append([]model.ImportError{}, invalid[:min(len(invalid), maxJobErrors)]...),
+	}
+	if err := s.JobRepo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	go s.runJob(*job, rows)
+
+	return job, nil
+}
+
+func (s *ImportService) runJob(job model.ImportJob, rows []model.ImportRow) {
+	// the request that started the job is gone, so the job gets its own context
+	ctx := context.Background()
+
+	job.Status = "running"
+	if err := s.JobRepo.UpdateProgress(ctx, &job); err != nil {
+		slog.Error("import job", "job", job.ID, "err", err)
+	}
+
+	for start := 0; start < len(rows); start += importChunkSize {
+		end := min(start+importChunkSize, len(rows))
+
+		imported, failures, err := s.importChunk(ctx, job.UserID, rows[start:end])
+		if err != nil {
+			job.Status = "failed"
+			job.Error = err.Error()
+			if err := s.JobRepo.UpdateProgress(ctx, &job); err != nil {
+				slog.Error("import job", "job", job.ID, "err", err)
+			}
+			return
+		}
+
+		job.Processed += end - start
+		job.Imported += imported
+		job.Failed += len(failures)
+		job.Errors = append(job.Errors, failures[:min(len(failures), max(maxJobErrors-len(job.Errors), 0))]...)
+		if err := s.JobRepo.UpdateProgress(ctx, &job); err != nil {
+			slog.Error("import job", "job", job.ID, "err", err)
+		}
+	}
+
+	job.Status = "succeeded"
+	if err := s.JobRepo.UpdateProgress(ctx, &job); err != nil {
+		slog.Error("import job", "job", job.ID, "err", err)
+	}
+}
+
+func (s *ImportService) Job(ctx context.Context, userID uuid.UUID, jobID string) (*model.ImportJob, error) {
+	return s.JobRepo.GetJob(ctx, jobID, userID)
+}
+
+// RecoverJobs fails jobs an earlier process left unfinished; call it on startup.
+func (s *ImportService) RecoverJobs(ctx context.Context) error {
+	return s.JobRepo.FailInterrupted(ctx)
+}
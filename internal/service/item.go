@@ -2,50 +2,128 @@ package service
 
 import (
 	"context"
+	"mastery-project/internal/config"
 	"mastery-project/internal/model"
 	"mastery-project/internal/repository"
+
+	"github.com/google/uuid"
 )
 
 type ItemService struct {
-	ItemRepo *repository.ItemRepository
+	ItemRepo          *repository.ItemRepository
+	variants          *VariantService
+	maxAttachments    int
+	maxBulkOperations int
+	quota             model.Quota
 }
 
-func NewItemService(itemRepo *repository.ItemRepository) *ItemService {
-	return &ItemService{ItemRepo: itemRepo}
+func NewItemService(itemRepo *repository.ItemRepository, variants *VariantService, cfg config.Items) *ItemService {
+	return &ItemService{
+		ItemRepo:          itemRepo,
+		variants:          variants,
+		maxAttachments:    cfg.MaxAttachments,
+		maxBulkOperations: cfg.MaxBulkOperations,
+		quota:             configuredQuota(cfg),
+	}
 }
 
-func (is *ItemService) Save(ctx context.Context, itemReq model.Item) error {
-	err := is.ItemRepo.CreateItem(ctx, itemReq)
+func (is *ItemService) Save(ctx context.Context, itemReq *model.Item) error {
+	if err := is.validateMetadata(ctx, itemReq.UserID, itemReq.Metadata); err != nil {
+		return err
+	}
+	err := is.ItemRepo.CreateItem(ctx, itemReq, is.quota)
 	if err != nil {
 		return err
 	}
 	return nil
 }
-func (is *ItemService) GetOne(ctx context.Context, itemId string) (*model.Item, error) {
+
+// GetOne returns the item with its attachments if userID may view it.
+func (is *ItemService) GetOne(ctx context.Context, itemId string, userID uuid.UUID) (*model.Item, error) {
+	permission, err := is.authorizeView(ctx, itemId, userID)
+	if err != nil {
+		return nil, err
+	}
 	item, err := is.ItemRepo.GetItemByID(ctx, itemId)
 	if err != nil {
 		return nil, err
 	}
+	item.Permission = permission
+	item.Attachments, err = is.ItemRepo.GetAttachments(ctx, itemId)
+	if err != nil {
+		return nil, err
+	}
 	return item, nil
 }
-func (is *ItemService) GetAll(ctx context.Context) ([]model.Item, error) {
-	items, err := is.ItemRepo.GetAllItems(ctx)
+
+// GetAll lists the items userID owns together with those shared with them,
+// narrowed by query, with the URLs of their image variants.
+func (is *ItemService) GetAll(ctx context.Context, userID uuid.UUID, query model.ItemQuery) ([]model.Item, error) {
+	items, err := is.ItemRepo.GetAllItems(ctx, userID, query)
 	if err != nil {
 		return nil, err
 	}
+	if err := is.variants.Attach(ctx, items); err != nil {
+		return nil, err
+	}
 	return items, nil
 }
-func (is *ItemService) Delete(ctx context.Context, itemID string) error {
-	err := is.ItemRepo.DeleteItemByID(ctx, itemID)
+
+// Delete removes the item; only its owner may do so.
+func (is *ItemService) Delete(ctx context.Context, itemID string, userID uuid.UUID, expectedVersion int) error {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionOwner); err != nil {
+		return err
+	}
+	err := is.ItemRepo.DeleteItemByID(ctx, itemID, expectedVersion)
 	if err != nil {
 		return err
 	}
 	return nil
 }
-func (is *ItemService) Update(ctx context.Context, itemId string, item model.UpdateItem) error {
-	err := is.ItemRepo.UpdateItemByID(ctx, itemId, item)
+
+// Update applies the change if the item is still at expectedVersion (0 means
+// unconditionally) and returns the resulting version. Editors may change the
+// content, but only the owner may change the visibility. New metadata is
+// checked against the owner's schema, whoever makes the change.
+func (is *ItemService) Update(ctx context.Context, itemId string, userID uuid.UUID, expectedVersion int, item model.UpdateItem) (int, error) {
+	need := model.PermissionEditor
+	if item.Visibility != nil {
+		need = model.PermissionOwner
+	}
+	if _, err := is.authorize(ctx, itemId, userID, need); err != nil {
+		return 0, err
+	}
+	if item.Metadata != nil {
+		current, err := is.ItemRepo.GetItemByID(ctx, itemId)
+		if err != nil {
+			return 0, err
+		}
+		if err := is.validateMetadata(ctx, current.UserID, item.Metadata); err != nil {
+			return 0, err
+		}
+	}
+	version, err := is.ItemRepo.UpdateItemByID(ctx, itemId, userID, expectedVersion, item)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return version, nil
+}
+
+// ReplaceImage points the item at filePath with its checksum and size (an
+// empty filePath removes the image) and returns the file name it used before.
+func (is *ItemService) ReplaceImage(ctx context.Context, itemID string, userID uuid.UUID, expectedVersion int, filePath, checksum string, size int64) (string, *model.Item, error) {
+	permission, err := is.authorize(ctx, itemID, userID, model.PermissionEditor)
+	if err != nil {
+		return "", nil, err
+	}
+	previous, _, err := is.ItemRepo.SetItemImage(ctx, itemID, expectedVersion, filePath, checksum, size, is.quota)
+	if err != nil {
+		return "", nil, err
+	}
+	item, err := is.ItemRepo.GetItemByID(ctx, itemID)
+	if err != nil {
+		return "", nil, err
+	}
+	item.Permission = permission
+	return previous, item, nil
 }
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"mastery-project/internal/model"
+	"mastery-project/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func (is *ItemService) Attachments(ctx context.Context, itemID string, userID uuid.UUID) ([]model.Attachment, error) {
+	if _, err := is.authorizeView(ctx, itemID, userID); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetAttachments(ctx, itemID)
+}
+
+func (is *ItemService) AddAttachment(ctx context.Context, userID uuid.UUID, attachment *model.Attachment) error {
+	if _, err := is.authorize(ctx, attachment.ItemID.String(), userID, model.PermissionEditor); err != nil {
+		return err
+	}
+	return is.ItemRepo.AddAttachment(ctx, attachment, is.maxAttachments, is.quota)
+}
+
+func (is *ItemService) ReorderAttachments(ctx context.Context, itemID string, userID uuid.UUID, ids []uuid.UUID) ([]model.Attachment, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionEditor); err != nil {
+		return nil, err
+	}
+	if err := is.ItemRepo.ReorderAttachments(ctx, itemID, ids); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetAttachments(ctx, itemID)
+}
+
+func (is *ItemService) DeleteAttachment(ctx context.Context, itemID string, userID uuid.UUID, attachmentID string) (*model.Attachment, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionEditor); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.DeleteAttachment(ctx, itemID, attachmentID)
+}
+
+// PinFile keeps a stored file while the row that will reference it is saved.
+func (is *ItemService) PinFile(ctx context.Context, fileName, checksum string, size int64) error {
+	return is.ItemRepo.PinBlob(ctx, fileName, checksum, size)
+}
+
+func (is *ItemService) UnpinFile(ctx context.Context, fileName string) error {
+	return is.ItemRepo.UnpinBlob(ctx, fileName)
+}
+
+// ReleaseFile runs remove when no item image or attachment references the
+// file any more and no upload has it pinned, and reports whether it did.
+func (is *ItemService) ReleaseFile(ctx context.Context, fileName string, remove func(context.Context) error) (bool, error) {
+	return is.ItemRepo.ReleaseBlob(ctx, fileName, remove)
+}
+
+// BackfillAttachments records the size and checksum of attachments stored
+// before they were tracked, reading each file from blobs. A file that cannot be
+// read is logged and tried again on the next start. Call it on startup.
+func (is *ItemService) BackfillAttachments(ctx context.Context, blobs storage.Blob) error {
+	attachments, err := is.ItemRepo.LegacyAttachments(ctx)
+	if err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		checksum, size, err := hashBlob(ctx, blobs, attachment.FileName)
+		if err != nil {
+			slog.Error("backfilling attachment", "attachment", attachment.ID, "file", attachment.FileName, "err", err)
+			continue
+		}
+		if err := is.ItemRepo.SetAttachmentContent(ctx, &attachment, checksum, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashBlob returns the hex SHA-256 and the size of a stored file.
+func hashBlob(ctx context.Context, blobs storage.Blob, key string) (string, int64, error) {
+	file, _, err := blobs.Get(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
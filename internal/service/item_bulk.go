@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var ErrBulkTooLarge = errors.New("too many bulk operations")
+
+// CheckBulkSize rejects batches larger than the configured maximum.
+func (is *ItemService) CheckBulkSize(n int) error {
+	if is.maxBulkOperations > 0 && n > is.maxBulkOperations {
+		return fmt.Errorf("%w: at most %d per request", ErrBulkTooLarge, is.maxBulkOperations)
+	}
+	return nil
+}
+
+// Bulk runs already validated operations for userID, all-or-nothing when atomic.
+// Created items get empty metadata, so creates fail when that does not satisfy
+// the user's metadata schema.
+func (is *ItemService) Bulk(ctx context.Context, userID uuid.UUID, ops []model.BulkOperation, atomic bool) (*repository.BulkOutcome, error) {
+	if err := is.CheckBulkSize(len(ops)); err != nil {
+		return nil, err
+	}
+
+	rejected, ops, err := rejectCreates(ctx, is.ItemRepo, userID, ops)
+	if err != nil {
+		return nil, err
+	}
+	if len(rejected) > 0 && atomic {
+		return &repository.BulkOutcome{Results: rejected}, rejected[0].Err
+	}
+
+	outcome := &repository.BulkOutcome{}
+	if len(ops) > 0 {
+		outcome, err = is.ItemRepo.RunBulk(ctx, userID, ops, atomic, is.quota)
+		if outcome == nil {
+			return nil, err
+		}
+	}
+	outcome.Results = append(rejected, outcome.Results...)
+	return outcome, err
+}
+
+// rejectCreates fails the create operations in ops when empty metadata does
+// not satisfy userID's metadata schema, and returns the other operations.
+func rejectCreates(ctx context.Context, itemRepo *repository.ItemRepository, userID uuid.UUID, ops []model.BulkOperation) ([]repository.BulkOpResult, []model.BulkOperation, error) {
+	creates := false
+	for _, op := range ops {
+		creates = creates || op.Op == "create"
+	}
+	if !creates {
+		return nil, ops, nil
+	}
+
+	err := validateMetadata(ctx, itemRepo, userID, model.Metadata{})
+	if err == nil {
+		return nil, ops, nil
+	}
+	if !errors.Is(err, ErrMetadataInvalid) {
+		return nil, nil, err
+	}
+
+	var rejected []repository.BulkOpResult
+	var rest []model.BulkOperation
+	for _, op := range ops {
+		if op.Op == "create" {
+			rejected = append(rejected, repository.BulkOpResult{Index: op.Index, Op: op.Op, Err: err})
+		} else {
+			rest = append(rest, op)
+		}
+	}
+	return rejected, rest, nil
+}
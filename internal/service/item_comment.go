@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxMentions caps the number of distinct @names looked up per comment.
+const maxMentions = 20
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])@([\p{L}\p{N}_.\-]+)`)
+
+// Comments returns a page of the item's comments to anyone who can see it.
+func (is *ItemService) Comments(ctx context.Context, itemID string, userID uuid.UUID, limit, offset int) (*model.CommentsPage, error) {
+	if _, err := is.authorizeView(ctx, itemID, userID); err != nil {
+		return nil, err
+	}
+	comments, total, err := is.ItemRepo.GetComments(ctx, itemID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CommentsPage{
+		Comments: comments,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	}, nil
+}
+
+// AddComment posts a comment, or a reply when ParentID is set, as userID.
+func (is *ItemService) AddComment(ctx context.Context, itemID string, userID uuid.UUID, req model.CreateComment) (*model.Comment, error) {
+	if _, err := is.authorizeView(ctx, itemID, userID); err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return nil, repository.ErrItemNotFound
+	}
+
+	comment := &model.Comment{
+		ItemID:   id,
+		UserID:   &userID,
+		ParentID: req.ParentID,
+		Body:     req.Body,
+	}
+	if err := is.ItemRepo.CreateComment(ctx, comment, parseMentions(req.Body)); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetComment(ctx, itemID, comment.ID.String())
+}
+
+// EditComment changes the body of one of userID's own comments.
+func (is *ItemService) EditComment(ctx context.Context, itemID, commentID string, userID uuid.UUID, req model.UpdateComment) (*model.Comment, error) {
+	if _, err := is.authorizeView(ctx, itemID, userID); err != nil {
+		return nil, err
+	}
+	comment, err := is.ItemRepo.GetComment(ctx, itemID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID == nil || *comment.UserID != userID {
+		return nil, repository.ErrForbidden
+	}
+
+	comment.Body = req.Body
+	if err := is.ItemRepo.UpdateComment(ctx, comment, parseMentions(req.Body)); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetComment(ctx, itemID, commentID)
+}
+
+// DeleteComment removes a comment and its replies. Authors can delete their own
+// comments and the item's owner can delete any comment on it.
+func (is *ItemService) DeleteComment(ctx context.Context, itemID, commentID string, userID uuid.UUID) error {
+	permission, err := is.authorizeView(ctx, itemID, userID)
+	if err != nil {
+		return err
+	}
+	comment, err := is.ItemRepo.GetComment(ctx, itemID, commentID)
+	if err != nil {
+		return err
+	}
+	isAuthor := comment.UserID != nil && *comment.UserID == userID
+	if !isAuthor && permission != model.PermissionOwner {
+		return repository.ErrForbidden
+	}
+	return is.ItemRepo.DeleteComment(ctx, itemID, commentID)
+}
+
+// parseMentions returns the distinct, lower-cased names mentioned as @name.
+func parseMentions(body string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.ToLower(strings.TrimRight(match[1], ".-"))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+	return names
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var ErrNoImage = errors.New("item has no image")
+
+// ItemImage returns an item userID can see, for serving its image.
+func (is *ItemService) ItemImage(ctx context.Context, itemID string, userID uuid.UUID) (*model.Item, error) {
+	if _, err := is.authorizeView(ctx, itemID, userID); err != nil {
+		return nil, err
+	}
+	return is.imageItem(ctx, itemID)
+}
+
+// SignedImage returns an item without checking access; the caller has already
+// verified a signed URL for it.
+func (is *ItemService) SignedImage(ctx context.Context, itemID string) (*model.Item, error) {
+	return is.imageItem(ctx, itemID)
+}
+
+// PublicImage returns an unlisted or public item, for serving its image to anyone.
+func (is *ItemService) PublicImage(ctx context.Context, itemID string) (*model.Item, error) {
+	item, err := is.imageItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Visibility == model.VisibilityPrivate {
+		return nil, repository.ErrItemNotFound
+	}
+	return item, nil
+}
+
+// AttachmentFile returns an attachment of an item userID can see.
+func (is *ItemService) AttachmentFile(ctx context.Context, itemID string, userID uuid.UUID, attachmentID string) (*model.Attachment, error) {
+	if _, err := is.authorizeView(ctx, itemID, userID); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetAttachment(ctx, itemID, attachmentID)
+}
+
+// PublicAttachmentFile returns an attachment of an unlisted or public item.
+func (is *ItemService) PublicAttachmentFile(ctx context.Context, itemID, attachmentID string) (*model.Attachment, error) {
+	item, err := is.ItemRepo.GetItemByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Visibility == model.VisibilityPrivate {
+		return nil, repository.ErrItemNotFound
+	}
+	return is.ItemRepo.GetAttachment(ctx, itemID, attachmentID)
+}
+
+func (is *ItemService) imageItem(ctx context.Context, itemID string) (*model.Item, error) {
+	item, err := is.ItemRepo.GetItemByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.FilePath == "" {
+		return nil, ErrNoImage
+	}
+	return item, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrLinkExpiry   = errors.New("expires_at must be in the future")
+	ErrLinkPassword = errors.New("incorrect password")
+)
+
+// CreateLink makes a new public link to the item. The returned link carries
+// the token; it cannot be retrieved again later.
+func (is *ItemService) CreateLink(ctx context.Context, itemID string, userID uuid.UUID, req model.CreateLinkRequest) (*model.ItemLink, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionOwner); err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrLinkExpiry
+	}
+
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return nil, repository.ErrItemNotFound
+	}
+
+	link := &model.ItemLink{
+		ItemID:    id,
+		CreatedBy: &userID,
+		Token:     rand.Text(),
+		ExpiresAt: req.ExpiresAt,
+		MaxViews:  req.MaxViews,
+	}
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		link.PasswordHash = string(hash)
+	}
+
+	if err := is.ItemRepo.CreateLink(ctx, link, hashToken(link.Token)); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (is *ItemService) Links(ctx context.Context, itemID string, userID uuid.UUID) ([]model.ItemLink, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionOwner); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetLinks(ctx, itemID)
+}
+
+func (is *ItemService) RevokeLink(ctx context.Context, itemID string, userID uuid.UUID, linkID string) (*model.ItemLink, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionOwner); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.RevokeLink(ctx, itemID, linkID)
+}
+
+// ResolveLink finds the link for token, failing with repository.ErrLinkGone
+// once it can no longer be opened.
+func (is *ItemService) ResolveLink(ctx context.Context, token string) (*model.ItemLink, error) {
+	link, err := is.ItemRepo.GetLinkByToken(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if !link.Usable(time.Now()) {
+		return nil, repository.ErrLinkGone
+	}
+	return link, nil
+}
+
+// ViewLink checks the password, counts the view and returns the linked item.
+func (is *ItemService) ViewLink(ctx context.Context, link *model.ItemLink, password string) (*model.Item, error) {
+	if link.HasPassword {
+		if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)); err != nil {
+			return nil, ErrLinkPassword
+		}
+	}
+	if err := is.ItemRepo.CountLinkView(ctx, link.ID.String()); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetItemByID(ctx, link.ItemID.String())
+}
+
+// LinkImage returns the linked item for serving its image. Image requests are
+// authorised by a signed URL from a counted view, so they are not counted
+// again and still work after the last allowed view, but not once the link
+// has expired or been revoked.
+func (is *ItemService) LinkImage(ctx context.Context, token string) (*model.Item, error) {
+	link, err := is.ItemRepo.GetLinkByToken(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if link.RevokedAt != nil || (link.ExpiresAt != nil && !time.Now().Before(*link.ExpiresAt)) {
+		return nil, repository.ErrLinkGone
+	}
+	return is.ItemRepo.GetItemByID(ctx, link.ItemID.String())
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
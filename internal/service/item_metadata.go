@@ -0,0 +1,94 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+var (
+	ErrInvalidMetadataSchema = errors.New("invalid metadata schema")
+	ErrMetadataInvalid       = errors.New("metadata does not match the schema")
+)
+
+// metadataSchemaURL names the schema resource inside the compiler; it is never fetched.
+const metadataSchemaURL = "urn:mastery:metadata-schema"
+
+func (is *ItemService) MetadataSchema(ctx context.Context, userID uuid.UUID) (json.RawMessage, error) {
+	return is.ItemRepo.GetMetadataSchema(ctx, userID)
+}
+
+// SetMetadataSchema stores the JSON Schema the metadata of userID's items must
+// satisfy, after checking that it compiles. Items already stored are not
+// re-validated.
+func (is *ItemService) SetMetadataSchema(ctx context.Context, userID uuid.UUID, schema json.RawMessage) error {
+	if _, err := compileMetadataSchema(schema); err != nil {
+		return err
+	}
+	return is.ItemRepo.SetMetadataSchema(ctx, userID, schema)
+}
+
+func (is *ItemService) DeleteMetadataSchema(ctx context.Context, userID uuid.UUID) error {
+	return is.ItemRepo.DeleteMetadataSchema(ctx, userID)
+}
+
+// validateMetadata checks metadata against the schema of the item's owner, if
+// they have one.
+func (is *ItemService) validateMetadata(ctx context.Context, ownerID uuid.UUID, metadata model.Metadata) error {
+	return validateMetadata(ctx, is.ItemRepo, ownerID, metadata)
+}
+
+func validateMetadata(ctx context.Context, itemRepo *repository.ItemRepository, ownerID uuid.UUID, metadata model.Metadata) error {
+	raw, err := itemRepo.GetMetadataSchema(ctx, ownerID)
+	if errors.Is(err, repository.ErrMetadataSchemaNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	schema, err := compileMetadataSchema(raw)
+	if err != nil {
+		return err
+	}
+
+	// round-trip so numbers reach the validator in the form it expects
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMetadataInvalid, err)
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(encoded))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMetadataInvalid, err)
+	}
+	if err := schema.Validate(instance); err != nil {
+		return fmt.Errorf("%w: %v", ErrMetadataInvalid, err)
+	}
+	return nil
+}
+
+// compileMetadataSchema compiles a user's schema. References to other
+// documents are not resolved, so a schema cannot make the server read files or
+// fetch URLs.
+func compileMetadataSchema(raw json.RawMessage) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetadataSchema, err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := compiler.AddResource(metadataSchemaURL, doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetadataSchema, err)
+	}
+	schema, err := compiler.Compile(metadataSchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetadataSchema, err)
+	}
+	return schema, nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Favorite adds an item from userID's list to their favorites.
+func (is *ItemService) Favorite(ctx context.Context, itemID string, userID uuid.UUID) error {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionViewer); err != nil {
+		return err
+	}
+	return is.ItemRepo.FavoriteItem(ctx, userID, itemID)
+}
+
+func (is *ItemService) Unfavorite(ctx context.Context, itemID string, userID uuid.UUID) error {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionViewer); err != nil {
+		return err
+	}
+	return is.ItemRepo.UnfavoriteItem(ctx, userID, itemID)
+}
+
+// MoveItem repositions an item within userID's own ordering of their list and
+// returns its new position key. Anyone with access can arrange their own list.
+func (is *ItemService) MoveItem(ctx context.Context, itemID string, userID uuid.UUID, req model.MoveItemRequest) (string, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionViewer); err != nil {
+		return "", err
+	}
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return "", repository.ErrItemNotFound
+	}
+	return is.ItemRepo.MoveItem(ctx, userID, id, req.After, req.Before)
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+)
+
+// PublicItems returns a page of the public feed.
+func (is *ItemService) PublicItems(ctx context.Context, limit, offset int) ([]model.Item, error) {
+	return is.ItemRepo.GetPublicItems(ctx, limit, offset)
+}
+
+// PublicItem returns an unlisted or public item with its attachments to anyone.
+func (is *ItemService) PublicItem(ctx context.Context, itemID string) (*model.Item, error) {
+	item, err := is.ItemRepo.GetItemByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Visibility == model.VisibilityPrivate {
+		return nil, repository.ErrItemNotFound
+	}
+	item.Attachments, err = is.ItemRepo.GetAttachments(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func (is *ItemService) Revisions(ctx context.Context, itemID string, userID uuid.UUID) ([]model.ItemRevision, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionViewer); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetRevisions(ctx, itemID)
+}
+
+func (is *ItemService) Revision(ctx context.Context, itemID string, userID uuid.UUID, revision int) (*model.ItemRevision, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionViewer); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetRevision(ctx, itemID, revision)
+}
+
+// DiffRevisions compares two revisions of the same item field by field.
+func (is *ItemService) DiffRevisions(ctx context.Context, itemID string, userID uuid.UUID, from, to int) (*model.RevisionDiff, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionViewer); err != nil {
+		return nil, err
+	}
+	fromRev, err := is.ItemRepo.GetRevision(ctx, itemID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := is.ItemRepo.GetRevision(ctx, itemID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &model.RevisionDiff{
+		ItemID:  fromRev.ItemID,
+		From:    from,
+		To:      to,
+		Changes: []model.FieldChange{},
+	}
+	if fromRev.Title != toRev.Title {
+		diff.Changes = append(diff.Changes, model.FieldChange{Field: "title", From: fromRev.Title, To: toRev.Title})
+	}
+	if fromRev.Description != toRev.Description {
+		diff.Changes = append(diff.Changes, model.FieldChange{Field: "description", From: fromRev.Description, To: toRev.Description})
+	}
+	// revisions from before visibility and metadata were recorded have
+	// nothing to compare
+	if fromRev.Visibility != nil && toRev.Visibility != nil && *fromRev.Visibility != *toRev.Visibility {
+		diff.Changes = append(diff.Changes, model.FieldChange{Field: "visibility", From: *fromRev.Visibility, To: *toRev.Visibility})
+	}
+	if fromRev.Metadata != nil && toRev.Metadata != nil {
+		from, to := metadataJSON(fromRev.Metadata), metadataJSON(toRev.Metadata)
+		if from != to {
+			diff.Changes = append(diff.Changes, model.FieldChange{Field: "metadata", From: from, To: to})
+		}
+	}
+	return diff, nil
+}
+
+func (is *ItemService) RestoreRevision(ctx context.Context, itemID string, revision int, userID uuid.UUID, expectedVersion int) (*model.Item, error) {
+	permission, err := is.authorize(ctx, itemID, userID, model.PermissionEditor)
+	if err != nil {
+		return nil, err
+	}
+	// restored metadata must still satisfy the owner's current schema
+	rev, err := is.ItemRepo.GetRevision(ctx, itemID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if rev.Metadata != nil {
+		current, err := is.ItemRepo.GetItemByID(ctx, itemID)
+		if err != nil {
+			return nil, err
+		}
+		if err := is.validateMetadata(ctx, current.UserID, rev.Metadata); err != nil {
+			return nil, err
+		}
+	}
+	// only the owner may change visibility, so editors' restores keep the
+	// current one
+	withVisibility := permission == model.PermissionOwner
+	if _, err := is.ItemRepo.RestoreRevision(ctx, itemID, revision, userID, expectedVersion, withVisibility); err != nil {
+		return nil, err
+	}
+	item, err := is.ItemRepo.GetItemByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	item.Permission = permission
+	return item, nil
+}
+
+// metadataJSON renders metadata for a diff. Map keys are sorted, so equal
+// metadata renders the same.
+func metadataJSON(metadata model.Metadata) string {
+	out, _ := json.Marshal(metadata)
+	return string(out)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// authorize returns userID's permission on the item if it is at least need.
+// Users without any access get repository.ErrItemNotFound, so the item's
+// existence is not revealed; users who can see it but need more access get
+// repository.ErrForbidden.
+func (is *ItemService) authorize(ctx context.Context, itemID string, userID uuid.UUID, need string) (string, error) {
+	permission, visibility, err := is.ItemRepo.ItemAccess(ctx, itemID, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := repository.CheckAccess(permission, visibility, need, false); err != nil {
+		return "", err
+	}
+	return permission, nil
+}
+
+// authorizeView is authorize for reading the item itself, which unlisted and
+// public items allow anyone to do. The permission is "" for such readers.
+func (is *ItemService) authorizeView(ctx context.Context, itemID string, userID uuid.UUID) (string, error) {
+	permission, visibility, err := is.ItemRepo.ItemAccess(ctx, itemID, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := repository.CheckAccess(permission, visibility, model.PermissionViewer, true); err != nil {
+		return "", err
+	}
+	return permission, nil
+}
+
+// SharedWithMe lists the items other users have shared with userID.
+func (is *ItemService) SharedWithMe(ctx context.Context, userID uuid.UUID) ([]model.Item, error) {
+	return is.ItemRepo.GetSharedItems(ctx, userID)
+}
+
+func (is *ItemService) Shares(ctx context.Context, itemID string, userID uuid.UUID) ([]model.ItemShare, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionOwner); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetShares(ctx, itemID)
+}
+
+func (is *ItemService) Share(ctx context.Context, itemID string, userID uuid.UUID, req model.ShareItemRequest) (*model.ItemShare, error) {
+	if _, err := is.authorize(ctx, itemID, userID, model.PermissionOwner); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.ShareItem(ctx, itemID, req.Email, req.Permission)
+}
+
+// Unshare revokes granteeID's access. The owner can revoke anyone's share;
+// other users can only remove their own.
+func (is *ItemService) Unshare(ctx context.Context, itemID string, userID, granteeID uuid.UUID) error {
+	need := model.PermissionOwner
+	if granteeID == userID {
+		need = model.PermissionViewer
+	}
+	if _, err := is.authorize(ctx, itemID, userID, need); err != nil {
+		return err
+	}
+	return is.ItemRepo.DeleteShare(ctx, itemID, granteeID)
+}
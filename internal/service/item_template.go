@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// Duplicate copies an item userID can see into a new item they own, with the
+// overrides applied. The copy shares the source's image and attachment files,
+// starts a fresh history and is private unless the overrides say otherwise;
+// shares, links, comments and revisions are not copied.
+func (is *ItemService) Duplicate(ctx context.Context, itemID string, userID uuid.UUID, overrides model.ItemOverrides) (*model.Item, error) {
+	if _, err := is.authorizeView(ctx, itemID, userID); err != nil {
+		return nil, err
+	}
+	source, err := is.ItemRepo.GetItemByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &model.Item{
+		UserID:      userID,
+		Title:       source.Title,
+		Description: source.Description,
+		Visibility:  model.VisibilityPrivate,
+		Metadata:    source.Metadata,
+	}
+	overrides.Apply(item)
+	if err := is.validateMetadata(ctx, userID, item.Metadata); err != nil {
+		return nil, err
+	}
+
+	if err := is.ItemRepo.DuplicateItem(ctx, itemID, item, is.quota); err != nil {
+		return nil, err
+	}
+	item.Permission = model.PermissionOwner
+	item.Attachments, err = is.ItemRepo.GetAttachments(ctx, item.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// SaveTemplate stores the title, description, visibility and metadata of an
+// item userID can see as one of their templates.
+func (is *ItemService) SaveTemplate(ctx context.Context, itemID string, userID uuid.UUID, name string) (*model.ItemTemplate, error) {
+	if _, err := is.authorizeView(ctx, itemID, userID); err != nil {
+		return nil, err
+	}
+	source, err := is.ItemRepo.GetItemByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &model.ItemTemplate{
+		UserID:      userID,
+		Name:        name,
+		Title:       source.Title,
+		Description: source.Description,
+		Visibility:  source.Visibility,
+		Metadata:    source.Metadata,
+	}
+	if err := is.ItemRepo.CreateTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (is *ItemService) Templates(ctx context.Context, userID uuid.UUID) ([]model.ItemTemplate, error) {
+	return is.ItemRepo.GetTemplates(ctx, userID)
+}
+
+func (is *ItemService) Template(ctx context.Context, templateID string, userID uuid.UUID) (*model.ItemTemplate, error) {
+	return is.ItemRepo.GetTemplate(ctx, templateID, userID)
+}
+
+func (is *ItemService) DeleteTemplate(ctx context.Context, templateID string, userID uuid.UUID) error {
+	return is.ItemRepo.DeleteTemplate(ctx, templateID, userID)
+}
+
+// CreateFromTemplate creates a new item for userID from one of their templates
+// with the overrides applied.
+func (is *ItemService) CreateFromTemplate(ctx context.Context, templateID string, userID uuid.UUID, overrides model.ItemOverrides) (*model.Item, error) {
+	template, err := is.ItemRepo.GetTemplate(ctx, templateID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &model.Item{
+		UserID:      userID,
+		Title:       template.Title,
+		Description: template.Description,
+		Visibility:  template.Visibility,
+		Metadata:    template.Metadata,
+	}
+	overrides.Apply(item)
+	if err := is.Save(ctx, item); err != nil {
+		return nil, err
+	}
+	item.Permission = model.PermissionOwner
+	return item, nil
+}
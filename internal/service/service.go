@@ -1,17 +1,38 @@
 package service
 
-import "mastery-project/internal/repository"
+import (
+	"mastery-project/internal/config"
+	"mastery-project/internal/repository"
+	"mastery-project/internal/storage"
+)
 
 type Services struct {
-	Auth *AuthService
-	Item *ItemService
+	Auth       *AuthService
+	Item       *ItemService
+	Import     *ImportService
+	Collection *CollectionService
+	Variant    *VariantService
+	Upload     *UploadService
 }
 
-func NewServices(repo *repository.Repository) (*Services, error) {
+func NewServices(cfg *config.Config, repo *repository.Repository, blobs storage.Blob) (*Services, error) {
+	variantService, err := NewVariantService(repo.Item, blobs, cfg.Images)
+	if err != nil {
+		return nil, err
+	}
+	uploadService, err := NewUploadService(repo.Upload, blobs, cfg.Items)
+	if err != nil {
+		return nil, err
+	}
 	authService := NewAuthService(repo.User, repo.Session)
-	itemService := NewItemService(repo.Item)
+	itemService := NewItemService(repo.Item, variantService, cfg.Items)
+	importService := NewImportService(repo.Item, repo.ImportJob, cfg.Items)
 	return &Services{
-		Auth: authService,
-		Item: itemService,
+		Auth:       authService,
+		Item:       itemService,
+		Import:     importService,
+		Collection: NewCollectionService(repo.Collection),
+		Variant:    variantService,
+		Upload:     uploadService,
 	}, nil
 }
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mastery-project/internal/config"
+	"mastery-project/internal/filetype"
+	"mastery-project/internal/model"
+	"mastery-project/internal/repository"
+	"mastery-project/internal/storage"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// uploadChunkPrefix keeps the chunks of resumable uploads apart from stored files.
+const uploadChunkPrefix = "tus/"
+
+var (
+	ErrUploadTooLarge   = errors.New("upload is larger than its file type allows")
+	ErrUploadIncomplete = errors.New("upload is not complete")
+)
+
+// UploadService keeps resumable uploads: the bytes of each PATCH are stored as
+// a chunk blob, and an upload's data is read back by chaining its chunks.
+type UploadService struct {
+	UploadRepo *repository.UploadRepository
+	blobs      storage.Blob
+	types      *filetype.AllowList
+	expiry     time.Duration
+}
+
+func NewUploadService(uploadRepo *repository.UploadRepository, blobs storage.Blob, cfg config.Items) (*UploadService, error) {
+	types, err := filetype.ParseAllowList(cfg.UploadTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadService{
+		UploadRepo: uploadRepo,
+		blobs:      blobs,
+		types:      types,
+		expiry:     time.Duration(max(1, cfg.UploadExpiryHours)) * time.Hour,
+	}, nil
+}
+
+// MaxSize is the largest upload any allowed file type permits.
+func (us *UploadService) MaxSize() int64 {
+	return us.types.MaxSize()
+}
+
+// Create starts an upload of length bytes. The file name must have an allowed
+// extension and length must be within its type's limit, so a client learns
+// before sending any data that the file would be refused.
+func (us *UploadService) Create(ctx context.Context, upload *model.Upload) error {
+	rule, err := us.types.ForExtension(filepath.Ext(upload.FileName))
+	if err != nil {
+		return err
+	}
+	if upload.Length > rule.MaxSize {
+		return fmt.Errorf("%w: %s files are limited to %d bytes", ErrUploadTooLarge, rule.Name, rule.MaxSize)
+	}
+	upload.ExpiresAt = time.Now().Add(us.expiry)
+	return us.UploadRepo.CreateUpload(ctx, upload)
+}
+
+func (us *UploadService) Get(ctx context.Context, id, userID uuid.UUID) (*model.Upload, error) {
+	return us.UploadRepo.GetUpload(ctx, id, userID)
+}
+
+// Append stores data at the upload's current offset and advances it. It
+// fails with repository.ErrUploadOffset when a concurrent request got there
+// first; the chunk is then discarded.
+func (us *UploadService) Append(ctx context.Context, upload *model.Upload, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	chunk := uploadChunkPrefix + upload.ID.String() + "/" + rand.Text()
+	if err := us.blobs.Put(ctx, chunk, bytes.NewReader(data), int64(len(data)), "application/octet-stream"); err != nil {
+		return err
+	}
+	if err := us.UploadRepo.AppendChunk(ctx, upload, chunk, int64(len(data)), time.Now().Add(us.expiry)); err != nil {
+		us.deleteChunks(ctx, []string{chunk})
+		return err
+	}
+	return nil
+}
+
+// Open returns a reader over the data of a complete upload.
+func (us *UploadService) Open(ctx context.Context, id, userID uuid.UUID) (*model.Upload, io.ReadCloser, error) {
+	upload, err := us.UploadRepo.GetUpload(ctx, id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !upload.Complete() {
+		return nil, nil, ErrUploadIncomplete
+	}
+	return upload, &chunkReader{ctx: ctx, blobs: us.blobs, chunks: upload.Chunks}, nil
+}
+
+// Delete removes an upload and its chunks, when it is terminated or after an
+// item has used its data.
+func (us *UploadService) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	upload, err := us.UploadRepo.DeleteUpload(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	us.deleteChunks(ctx, upload.Chunks)
+	return nil
+}
+
+// PurgeExpired deletes expired uploads every interval until ctx is done.
+func (us *UploadService) PurgeExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			chunks, err := us.UploadRepo.PurgeExpired(ctx, now)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				slog.Error("uploads", "err", err)
+			}
+			us.deleteChunks(ctx, chunks)
+		}
+	}
+}
+
+func (us *UploadService) deleteChunks(ctx context.Context, chunks []string) {
+	for _, chunk := range chunks {
+		if err := us.blobs.Delete(ctx, chunk); err != nil {
+			slog.Warn("could not delete upload chunk", "chunk", chunk, "err", err)
+		}
+	}
+}
+
+// chunkReader reads an upload's chunks one after another, opening each only
+// when the previous one is used up.
+type chunkReader struct {
+	ctx     context.Context
+	blobs   storage.Blob
+	chunks  []string
+	current io.ReadCloser
+}
+
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if cr.current == nil {
+			if len(cr.chunks) == 0 {
+				return 0, io.EOF
+			}
+			reader, _, err := cr.blobs.Get(cr.ctx, cr.chunks[0])
+			if err != nil {
+				return 0, err
+			}
+			cr.current, cr.chunks = reader, cr.chunks[1:]
+		}
+
+		n, err := cr.current.Read(p)
+		if err == io.EOF {
+			cr.current.Close()
+			cr.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (cr *chunkReader) Close() error {
+	if cr.current == nil {
+		return nil
+	}
+	return cr.current.Close()
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"mastery-project/internal/config"
+	"mastery-project/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func configuredQuota(cfg config.Items) model.Quota {
+	return model.Quota{MaxBytes: int64(cfg.QuotaMaxBytes), MaxItems: cfg.QuotaMaxItems}
+}
+
+// Usage reports what userID's items take up against their quota.
+func (is *ItemService) Usage(ctx context.Context, userID uuid.UUID) (*model.Usage, error) {
+	return is.ItemRepo.GetUsage(ctx, userID, is.quota)
+}
+
+// SetQuota overrides userID's quota and returns their usage under it.
+func (is *ItemService) SetQuota(ctx context.Context, userID uuid.UUID, override model.QuotaOverride) (*model.Usage, error) {
+	if err := is.ItemRepo.SetQuota(ctx, userID, override); err != nil {
+		return nil, err
+	}
+	return is.ItemRepo.GetUsage(ctx, userID, is.quota)
+}
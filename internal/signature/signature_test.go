@@ -0,0 +1,46 @@
+package signature
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	valid := signer.Query("items/1/image", time.Minute)
+
+	tampered := func(key, value string) url.Values {
+		query := url.Values{"exp": {valid.Get("exp")}, "sig": {valid.Get("sig")}}
+		query.Set(key, value)
+		return query
+	}
+	flipped := []byte(valid.Get("sig"))
+	flipped[0] ^= 1
+
+	tests := []struct {
+		name    string
+		signer  *Signer
+		payload string
+		query   url.Values
+		want    bool
+	}{
+		{"valid", signer, "items/1/image", valid, true},
+		{"other payload", signer, "items/2/image", valid, false},
+		{"other key", NewSigner([]byte("other")), "items/1/image", valid, false},
+		{"expired", signer, "items/1/image", signer.Query("items/1/image", -time.Minute), false},
+		{"extended expiry", signer, "items/1/image", tampered("exp", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)), false},
+		{"altered signature", signer, "items/1/image", tampered("sig", string(flipped)), false},
+		{"signature not hex", signer, "items/1/image", tampered("sig", "not-hex"), false},
+		{"expiry not a number", signer, "items/1/image", tampered("exp", "soon"), false},
+		{"missing parameters", signer, "items/1/image", url.Values{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Verify(tt.payload, tt.query); got != tt.want {
+				t.Fatalf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrChecksumMismatch = errors.New("blob content does not match its checksum")
+
+// ContentKey is the key of a file stored under the hex SHA-256 of its content,
+// so identical files share one blob.
+func ContentKey(checksum, ext string) string {
+	return checksum + ext
+}
+
+// KeyChecksum returns the checksum a ContentKey was built from. Files stored
+// under random names before content addressing have none.
+func KeyChecksum(key string) (string, bool) {
+	sum := strings.TrimSuffix(path.Base(key), path.Ext(key))
+	if len(sum) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(sum); err != nil {
+		return "", false
+	}
+	return sum, true
+}
+
+// Verify checks data against a hex SHA-256 checksum.
+func Verify(data []byte, checksum string) error {
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != checksum {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// VerifyingReader hashes a blob of size bytes while it is read from start to
+// end, and fails the read that reaches the end with ErrChecksumMismatch, and
+// without its bytes, when the content does not match checksum, calling
+// onMismatch first. Reads that
+// start anywhere else, such as range requests, are passed through unchecked.
+func VerifyingReader(r io.ReadSeeker, size int64, checksum string, onMismatch func()) io.ReadSeeker {
+	return &verifyingReader{r: r, size: size, checksum: checksum, onMismatch: onMismatch, hash: sha256.New(), checking: true}
+}
+
+type verifyingReader struct {
+	r          io.ReadSeeker
+	size       int64
+	checksum   string
+	onMismatch func()
+	hash       hash.Hash
+	pos        int64
+	checking   bool
+}
+
+func (vr *verifyingReader) Read(p []byte) (int, error) {
+	n, err := vr.r.Read(p)
+	if vr.checking {
+		vr.hash.Write(p[:n])
+	}
+	vr.pos += int64(n)
+	if vr.checking && vr.pos == vr.size {
+		vr.checking = false
+		if hex.EncodeToString(vr.hash.Sum(nil)) != vr.checksum {
+			// withholding the last bytes leaves the response short of its
+			// Content-Length, so clients see it failed
+			vr.onMismatch()
+			return 0, ErrChecksumMismatch
+		}
+	}
+	return n, err
+}
+
+func (vr *verifyingReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := vr.r.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+	vr.pos = pos
+	vr.hash.Reset()
+	vr.checking = pos == 0
+	return pos, nil
+}
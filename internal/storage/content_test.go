@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestKeyChecksum(t *testing.T) {
+	sum := checksum([]byte("hello"))
+	tests := []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{ContentKey(sum, ".jpg"), sum, true},
+		{ContentKey(sum, ""), sum, true},
+		{"uploads/" + ContentKey(sum, ".pdf"), sum, true},
+		{"3f2a9c1e-6b7d-4e8f-9a0b-1c2d3e4f5a6b.jpg", "", false},
+		{sum[:63] + ".jpg", "", false},
+		{strings.Repeat("z", 64) + ".png", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, ok := KeyChecksum(tt.key)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("KeyChecksum(%q) = %q, %v; want %q, %v", tt.key, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	data := []byte("hello")
+	if err := Verify(data, checksum(data)); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := Verify([]byte("hellO"), checksum(data)); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestVerifyingReader(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	tests := []struct {
+		name     string
+		checksum string
+		seek     int64
+		want     []byte
+		err      error
+	}{
+		{"match", checksum(data), 0, data, nil},
+		{"mismatch", checksum([]byte("other")), 0, nil, ErrChecksumMismatch},
+		{"range is unchecked", checksum([]byte("other")), 10, data[10:], nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatches := 0
+			r := VerifyingReader(bytes.NewReader(data), int64(len(data)), tt.checksum, func() { mismatches++ })
+			if tt.seek != 0 {
+				if _, err := r.Seek(tt.seek, io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := io.ReadAll(r)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				// the read that reaches the end returns none of its bytes
+				if int64(len(got)) >= int64(len(data)) {
+					t.Errorf("read all %d bytes of a mismatched blob", len(got))
+				}
+				if mismatches != 1 {
+					t.Errorf("onMismatch called %d times, want 1", mismatches)
+				}
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("read %d bytes, want %d", len(got), len(tt.want))
+			}
+			if mismatches != 0 {
+				t.Errorf("onMismatch called %d times, want 0", mismatches)
+			}
+		})
+	}
+}
+
+func TestVerifyingReaderSeekBack(t *testing.T) {
+	data := []byte("hello, world")
+	mismatches := 0
+	r := VerifyingReader(bytes.NewReader(data), int64(len(data)), checksum([]byte("other")), func() { mismatches++ })
+
+	if _, err := r.Seek(5, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(r); err != nil {
+		t.Fatalf("ranged read: %v", err)
+	}
+	// seeking back to the start checks the next full read again
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v, want ErrChecksumMismatch", err)
+	}
+	if mismatches != 1 {
+		t.Errorf("onMismatch called %d times, want 1", mismatches)
+	}
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// tempPrefix marks files Put is still writing; List skips them.
+const tempPrefix = ".tmp-"
+
+// Local stores blobs as files under a root directory.
+type Local struct {
+	root string
+}
+
+// NewLocal stores blobs under root, which is resolved to an absolute path once
+// so later changes of the working directory do not move the store.
+func NewLocal(root string) (*Local, error) {
+	if root == "" {
+		return nil, errors.New("local storage root is not set")
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("local storage: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return nil, fmt.Errorf("local storage: %w", err)
+	}
+	return &Local{root: abs}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(filepath.FromSlash(key)) || strings.HasPrefix(path.Base(key), tempPrefix) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file next to the target and renames it into place,
+// so readers never see a partly written blob.
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), tempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	_, err = io.Copy(tmp, contextReader{ctx: ctx, r: r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadSeekCloser, *Info, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(target)
+	if err != nil {
+		return nil, nil, notFound(key, err)
+	}
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		file.Close()
+		return nil, nil, ErrNotFound
+	}
+	return file, localInfo(key, stat), nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (*Info, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(target)
+	if err != nil {
+		return nil, notFound(key, err)
+	}
+	if stat.IsDir() {
+		return nil, ErrNotFound
+	}
+	return localInfo(key, stat), nil
+}
+
+func (l *Local) List(ctx context.Context, prefix string, fn func(Info) error) error {
+	return filepath.WalkDir(l.root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		stat, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return fn(*localInfo(key, stat))
+	})
+}
+
+func localInfo(key string, stat fs.FileInfo) *Info {
+	return &Info{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ModTime:     stat.ModTime(),
+	}
+}
+
+func notFound(key string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("%s: %w", key, err)
+}
+
+// contextReader stops a copy once ctx is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mastery-project/internal/config"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 stores blobs as objects in a bucket of an S3-compatible service such as
+// AWS S3 or MinIO.
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3 connects to the configured service and creates the bucket if it does
+// not exist yet.
+func NewS3(ctx context.Context, cfg config.Storage) (*S3, error) {
+	if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+		return nil, errors.New("s3 storage needs STORAGE_S3_ENDPOINT and STORAGE_S3_BUCKET")
+	}
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: cfg.S3UseSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.S3Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.S3Bucket, minio.MakeBucketOptions{Region: cfg.S3Region}); err != nil {
+			return nil, fmt.Errorf("s3 storage: create bucket: %w", err)
+		}
+	}
+
+	prefix := strings.Trim(cfg.S3Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &S3{client: client, bucket: cfg.S3Bucket, prefix: prefix}, nil
+}
+
+func (s *S3) object(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains("/"+key+"/", "/../") {
+		return "", ErrInvalidKey
+	}
+	return s.prefix + key, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	name, err := s.object(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get returns an object that fetches ranges lazily as it is read and seeked.
+func (s *S3) Get(ctx context.Context, key string) (io.ReadSeekCloser, *Info, error) {
+	name, err := s.object(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s3Error(key, err)
+	}
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, s3Error(key, err)
+	}
+	return obj, s.info(stat), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	name, err := s.object(key)
+	if err != nil {
+		return err
+	}
+	if err := s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*Info, error) {
+	name, err := s.object(key)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(key, err)
+	}
+	return s.info(stat), nil
+}
+
+func (s *S3) List(ctx context.Context, prefix string, fn func(Info) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    s.prefix + prefix,
+		Recursive: true,
+	})
+	for obj := range objects {
+		if obj.Err != nil {
+			return fmt.Errorf("list %s: %w", prefix, obj.Err)
+		}
+		if err := fn(*s.info(obj)); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *S3) info(obj minio.ObjectInfo) *Info {
+	return &Info{
+		Key:         strings.TrimPrefix(obj.Key, s.prefix),
+		Size:        obj.Size,
+		ContentType: obj.ContentType,
+		ModTime:     obj.LastModified,
+	}
+}
+
+func s3Error(key string, err error) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return fmt.Errorf("%s: %w", key, err)
+}
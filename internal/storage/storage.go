@@ -0,0 +1,52 @@
+// Package storage stores uploaded files as blobs behind a driver-independent
+// interface, so every instance of the API can read what any other one wrote.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mastery-project/internal/config"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Info describes a stored blob.
+type Info struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Blob is a flat key/value store for file contents. Keys are slash-separated
+// relative paths.
+type Blob interface {
+	// Put streams r into key, replacing any existing blob. size may be -1 when
+	// unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob for reading; the caller must close it.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, *Info, error)
+	// Delete removes the blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (*Info, error)
+	// List calls fn for every blob whose key starts with prefix.
+	List(ctx context.Context, prefix string, fn func(Info) error) error
+}
+
+// New returns the driver selected in cfg.
+func New(ctx context.Context, cfg config.Storage) (Blob, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocal(cfg.LocalRoot)
+	case "s3":
+		return NewS3(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}